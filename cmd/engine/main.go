@@ -14,6 +14,7 @@ import (
 	//pb "reddit-clone/api/proto/generated"
 	internalActor "reddit-clone/internal/actor" // Alias the import
 	"reddit-clone/internal/store/memory"
+	"reddit-clone/pkg/config"
 	"reddit-clone/pkg/metrics"
 )
 
@@ -38,17 +39,18 @@ func main() {
 	// Create remote
 	remoting := remote.NewRemote(system, remoteConfig)
 
-	// Create new engine actor
-	engineActor := internalActor.NewEngineActor(
+	cfg := config.Default()
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid config: %v", err)
+	}
+
+	// Create props for the supervised engine actor
+	props := internalActor.NewEngineProps(
 		memory.NewMemoryStore(),
 		metricsCollector,
+		cfg,
 	)
 
-	// Create props
-	props := actor.PropsFromProducer(func() actor.Actor {
-		return engineActor
-	})
-
 	remoting.Register("engine", props)
 	remoting.Start()
 
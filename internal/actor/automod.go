@@ -0,0 +1,258 @@
+package actor
+
+import (
+	"fmt"
+	"github.com/asynkron/protoactor-go/actor"
+	"log"
+	pb "reddit-clone/api/proto/generated"
+	"reddit-clone/internal/automod"
+	"reddit-clone/internal/models"
+	"reddit-clone/pkg/utils"
+	"strings"
+	"time"
+)
+
+// autoModeratorID is the author of AutoModerator's replies and mod log entries
+const autoModeratorID = "AutoModerator"
+
+// rebuildAutoMod compiles every subreddit's stored rules after a restart.
+// A config that no longer parses, say after MaxRules was lowered, is skipped
+// so it cannot cost other subreddits their rules.
+func (e *EngineActor) rebuildAutoMod(subreddits []*models.Subreddit) {
+	for _, subreddit := range subreddits {
+		if subreddit.AutoModConfig == "" {
+			continue
+		}
+		rules, err := automod.Parse(subreddit.AutoModConfig, e.config.AutoMod.MaxRules)
+		if err != nil {
+			log.Printf("skipping AutoModerator config of subreddit %s: %v", subreddit.ID, err)
+			e.metrics.RecordError()
+			continue
+		}
+		e.automod[subreddit.ID] = rules
+	}
+}
+
+func (e *EngineActor) handleSetAutoModConfig(context actor.Context, msg *pb.SetAutoModConfigMessage) {
+	start := time.Now()
+
+	subreddit := e.requireModerator(context, msg.SubredditId, msg.ModeratorId, msg.SessionId)
+	if subreddit == nil {
+		return
+	}
+
+	text := strings.TrimSpace(msg.Config)
+	if limit := e.config.AutoMod.MaxConfigBytes; len(text) > limit {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: fmt.Sprintf("AutoModerator config must be at most %d bytes", limit)})
+		return
+	}
+	var rules automod.Rules
+	if text != "" {
+		var err error
+		if rules, err = automod.Parse(text, e.config.AutoMod.MaxRules); err != nil {
+			e.metrics.RecordError()
+			context.Respond(&pb.ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+	for _, rule := range rules {
+		if rule.SetFlair != "" && models.FindFlair(subreddit.PostFlairs, rule.SetFlair) == nil {
+			e.metrics.RecordError()
+			context.Respond(&pb.ErrorResponse{Error: fmt.Sprintf("rule %q: unknown post flair %q", rule.Name, rule.SetFlair)})
+			return
+		}
+	}
+
+	if err := e.store.SetAutoModConfig(subreddit.ID, text); err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error()})
+		return
+	}
+	// Takes effect from the next post, comment or report
+	if len(rules) == 0 {
+		delete(e.automod, subreddit.ID)
+	} else {
+		e.automod[subreddit.ID] = rules
+	}
+
+	e.logModAction(subreddit.ID, msg.ModeratorId, models.ModRuleChange, subreddit.ID, fmt.Sprintf("AutoModerator config: %d rules", len(rules)))
+
+	e.metrics.RecordRequest(time.Since(start).Seconds())
+	context.Respond(&pb.SuccessResponse{Message: "AutoModerator config updated successfully"})
+}
+
+func (e *EngineActor) handleGetAutoModConfig(context actor.Context, msg *pb.GetAutoModConfigMessage) {
+	start := time.Now()
+
+	subreddit := e.requireModerator(context, msg.SubredditId, msg.ModeratorId, msg.SessionId)
+	if subreddit == nil {
+		return
+	}
+
+	e.metrics.RecordRequest(time.Since(start).Seconds())
+	context.Respond(&pb.AutoModConfigResponse{
+		Config:    subreddit.AutoModConfig,
+		RuleCount: int32(len(e.automod[subreddit.ID])),
+	})
+}
+
+// autoModerate runs the subreddit's rules over new content, or over reported
+// content when reports is non-zero, takes the actions of every matching rule
+// and records each in the mod log. It returns true if the content was removed
+// or filtered, so callers can hold back announcing it.
+func (e *EngineActor) autoModerate(context actor.Context, target *contentTarget, reports int) bool {
+	rules := e.automod[target.SubredditID]
+	if len(rules) == 0 {
+		return false
+	}
+	subreddit, err := e.store.GetSubreddit(target.SubredditID)
+	if err != nil || isModerator(subreddit, target.AuthorID) {
+		return false
+	}
+
+	now := time.Now()
+	item := automod.Item{
+		Post:     target.Type == models.TargetPost,
+		Title:    target.Title,
+		Content:  target.Content,
+		Reports:  reports,
+		Reported: reports > 0,
+		Now:      now,
+	}
+	if author, err := e.store.GetUser(target.AuthorID); err == nil {
+		item.AuthorKarma = author.Karma
+		item.AuthorCreated = author.Created
+	}
+	var post *models.Post
+	if item.Post {
+		if post, err = e.store.GetPost(target.ID); err != nil {
+			return false
+		}
+		item.Domain = post.Domain
+		if post.Flair != nil {
+			item.FlairID = post.Flair.ID
+		}
+	}
+
+	matched := rules.Evaluate(item)
+	if len(matched) == 0 {
+		return false
+	}
+
+	// Removal outranks filtering, and approved content is never filtered again
+	hidden := false
+	modAction := models.ModRemove
+	rule := firstRule(matched, func(rule *automod.Rule) bool { return rule.Action == automod.Remove })
+	if rule == nil && !target.Approved {
+		modAction = models.ModFilter
+		rule = firstRule(matched, func(rule *automod.Rule) bool { return rule.Action == automod.Filter })
+	}
+	if rule != nil {
+		if modAction == models.ModRemove {
+			err = e.setRemoved(target, true)
+		} else {
+			err = e.store.FilterContent(&models.QueueItem{
+				TargetID:    target.ID,
+				TargetType:  target.Type,
+				SubredditID: target.SubredditID,
+				Created:     target.Created,
+			}, autoModReason(rule))
+		}
+		if err != nil {
+			e.metrics.RecordError()
+		} else {
+			e.recordAutoModAction(target.SubredditID, modAction, target.ID, rule)
+			hidden = true
+		}
+	}
+
+	if post != nil {
+		if rule := firstRule(matched, func(rule *automod.Rule) bool { return rule.SetFlair != "" }); rule != nil {
+			// The template may have been deleted since the rules were saved
+			if template := models.FindFlair(subreddit.PostFlairs, rule.SetFlair); template != nil {
+				flair := *template
+				if err := e.store.SetPostFlair(post.ID, &flair); err != nil {
+					e.metrics.RecordError()
+				} else {
+					e.recordAutoModAction(subreddit.ID, models.ModFlair, post.ID, rule)
+				}
+			}
+		}
+		if rule := firstRule(matched, func(rule *automod.Rule) bool { return rule.Lock }); rule != nil && !post.Locked {
+			if err := e.store.SetPostLocked(post.ID, true); err != nil {
+				e.metrics.RecordError()
+			} else {
+				e.recordAutoModAction(subreddit.ID, models.ModLock, post.ID, rule)
+			}
+		}
+	}
+
+	for _, rule := range matched {
+		if rule.Reply != "" {
+			e.autoModReply(context, target, rule, now)
+		}
+	}
+	return hidden
+}
+
+// autoModeratePost runs the rules over a newly created post
+func (e *EngineActor) autoModeratePost(context actor.Context, post *models.Post) bool {
+	target, err := e.findTarget(post.ID, models.TargetPost)
+	return err == nil && e.autoModerate(context, target, 0)
+}
+
+// autoModReply answers the content with a rule's reply, which notifies its author
+func (e *EngineActor) autoModReply(context actor.Context, target *contentTarget, rule *automod.Rule, now time.Time) {
+	comment := &models.Comment{
+		ID:       utils.GenerateID(),
+		PostID:   target.ID,
+		AuthorID: autoModeratorID,
+		Content:  rule.Reply,
+		Created:  now.Unix(),
+	}
+	if target.Type == models.TargetComment {
+		parent, err := e.store.GetComment(target.ID)
+		if err != nil {
+			return
+		}
+		comment.PostID, comment.ParentID = parent.PostID, parent.ID
+	}
+	post, err := e.store.GetPost(comment.PostID)
+	if err != nil {
+		return
+	}
+	if err := e.store.AddComment(comment); err != nil {
+		e.metrics.RecordError()
+		return
+	}
+
+	e.indexCommentText(comment, post.SubredditID)
+	e.notifyComment(context, comment, post)
+	e.publish(context, pb.SubscriptionTopic_TOPIC_POST, comment.PostID, &pb.EventMessage{
+		Type:    pb.EventType_EVENT_NEW_COMMENT,
+		Comment: commentMessage(comment),
+	})
+	e.recordAutoModAction(post.SubredditID, models.ModReply, target.ID, rule)
+}
+
+func (e *EngineActor) recordAutoModAction(subredditID string, action models.ModAction, targetID string, rule *automod.Rule) {
+	e.logModAction(subredditID, autoModeratorID, action, targetID, autoModReason(rule))
+	e.metrics.RecordAutoModAction(string(action))
+}
+
+func autoModReason(rule *automod.Rule) string {
+	if rule.Reason == "" {
+		return "rule " + rule.Name
+	}
+	return fmt.Sprintf("rule %s: %s", rule.Name, rule.Reason)
+}
+
+func firstRule(rules []*automod.Rule, match func(rule *automod.Rule) bool) *automod.Rule {
+	for _, rule := range rules {
+		if match(rule) {
+			return rule
+		}
+	}
+	return nil
+}
@@ -12,9 +12,14 @@ import (
 	"time"
 )
 
+// maxRecentPosts bounds how many pushed post IDs a client remembers as comment and vote targets
+const maxRecentPosts = 50
+
 type ClientActor struct {
 	userID       string
 	username     string
+	password     string
+	sessionID    string
 	enginePID    *protoactor.PID
 	connected    bool
 	subreddits   []string
@@ -22,6 +27,7 @@ type ClientActor struct {
 	behavior     *common.ClientBehavior
 	distribution *common.SimulationDistribution
 	persona      string
+	recentPosts  []string
 }
 
 func NewClientActor(userID string, username string, enginePID *protoactor.PID, behavior *common.ClientBehavior, metrics *metrics.RedditMetrics) *ClientActor {
@@ -30,6 +36,7 @@ func NewClientActor(userID string, username string, enginePID *protoactor.PID, b
 	return &ClientActor{
 		userID:     userID,
 		username:   uniqueName,
+		password:   utils.GenerateID(),
 		enginePID:  enginePID,
 		connected:  true,
 		subreddits: make([]string, 0),
@@ -43,6 +50,13 @@ func (c *ClientActor) Receive(context protoactor.Context) {
 	switch msg := context.Message().(type) {
 	case *pb.PingMessage:
 		context.Respond(&pb.PongMessage{})
+	case *protoactor.Started:
+		c.login(context)
+		c.subscribe(context)
+	case *protoactor.Stopping:
+		context.Request(c.enginePID, &pb.UnsubscribeAllMessage{})
+	case *pb.EventMessage:
+		c.handleEvent(msg)
 	case *common.SimulateAction:
 		if c.connected {
 			start := time.Now()
@@ -58,6 +72,15 @@ func (c *ClientActor) Receive(context protoactor.Context) {
 					c.metrics.RecordError()
 				}
 
+			case *generated.CrosspostMessage:
+				future := context.RequestFuture(c.enginePID, actionMsg, 5*time.Second)
+				if _, err := future.Result(); err == nil {
+					c.metrics.UpdateActiveUsers(1)
+					c.metrics.RecordAction(c.persona, "crosspost")
+				} else {
+					c.metrics.RecordError()
+				}
+
 			case *generated.CommentMessage:
 				future := context.RequestFuture(c.enginePID, actionMsg, 5*time.Second)
 				if _, err := future.Result(); err == nil {
@@ -111,11 +134,13 @@ func (c *ClientActor) Receive(context protoactor.Context) {
 		wasConnected := c.connected
 		c.connected = msg.Connected
 
-		// Update active users count
+		// Update active users count and live event subscriptions
 		if c.connected && !wasConnected {
 			c.metrics.UpdateActiveUsers(1)
+			c.subscribe(context)
 		} else if !c.connected && wasConnected {
 			c.metrics.UpdateActiveUsers(-1)
+			context.Request(c.enginePID, &pb.UnsubscribeAllMessage{})
 		}
 	}
 }
@@ -148,6 +173,57 @@ func (c *ClientActor) Receive(context protoactor.Context) {
 //	}
 //}
 
+// login registers the simulated user, if it is not already, and opens the
+// session its requests are made under
+func (c *ClientActor) login(context protoactor.Context) {
+	register := &pb.UserMessage{UserId: c.userID, Username: c.username, Password: c.password}
+	_ = context.RequestFuture(c.enginePID, register, 5*time.Second).Wait()
+
+	login := &pb.LoginMessage{UserId: c.userID, Password: c.password}
+	result, err := context.RequestFuture(c.enginePID, login, 5*time.Second).Result()
+	if response, ok := result.(*pb.LoginResponse); err == nil && ok {
+		c.sessionID = response.SessionId
+	} else {
+		c.metrics.RecordError()
+	}
+}
+
+// subscribe registers for pushes to the client's inbox and joined subreddits
+func (c *ClientActor) subscribe(context protoactor.Context) {
+	context.Request(c.enginePID, &pb.SubscribeMessage{
+		Topic:     pb.SubscriptionTopic_TOPIC_INBOX,
+		TargetId:  c.userID,
+		UserId:    c.userID,
+		SessionId: c.sessionID,
+	})
+	for _, subreddit := range c.subreddits {
+		context.Request(c.enginePID, &pb.SubscribeMessage{
+			Topic:     pb.SubscriptionTopic_TOPIC_SUBREDDIT,
+			TargetId:  subreddit,
+			UserId:    c.userID,
+			SessionId: c.sessionID,
+		})
+	}
+}
+
+func (c *ClientActor) handleEvent(event *pb.EventMessage) {
+	if event.Type != pb.EventType_EVENT_NEW_POST || event.Post == nil {
+		return
+	}
+	c.recentPosts = append(c.recentPosts, event.Post.Id)
+	if len(c.recentPosts) > maxRecentPosts {
+		c.recentPosts = c.recentPosts[len(c.recentPosts)-maxRecentPosts:]
+	}
+}
+
+// targetPost picks a post pushed to this client, or a random ID if none has arrived yet
+func (c *ClientActor) targetPost() string {
+	if len(c.recentPosts) == 0 {
+		return utils.GenerateID()
+	}
+	return c.recentPosts[rand.Intn(len(c.recentPosts))]
+}
+
 func (c *ClientActor) performAction(context protoactor.Context) interface{} {
 	//start := time.Now()
 
@@ -161,6 +237,9 @@ func (c *ClientActor) performAction(context protoactor.Context) interface{} {
 		//actionType = common.PostAction
 		//c.createPost(context)
 		c.metrics.RecordAction(c.persona, "post")
+		if crosspost := c.createCrosspost(); crosspost != nil {
+			return crosspost
+		}
 		return c.createPost(context)
 	case rand < c.behavior.PostProbability+c.behavior.CommentProbability:
 		//actionType = common.CommentAction
@@ -212,12 +291,29 @@ func (c *ClientActor) createPost(context protoactor.Context) *generated.PostMess
 		Content:     content,
 		CreatedAt:   time.Now().Unix(),
 		IsRepost:    isRepost,
+		SessionId:   c.sessionID,
 	}
 
 	context.Request(c.enginePID, post)
 	return post
 }
 
+// createCrosspost occasionally shares a recently seen post into another
+// subreddit the user belongs to, as the legitimate alternative to reposting.
+func (c *ClientActor) createCrosspost() *generated.CrosspostMessage {
+	if len(c.recentPosts) == 0 || len(c.subreddits) == 0 || rand.Float64() >= 0.1 {
+		return nil
+	}
+
+	return &generated.CrosspostMessage{
+		Id:             utils.GenerateID(),
+		OriginalPostId: c.targetPost(),
+		SubredditId:    c.subreddits[rand.Intn(len(c.subreddits))],
+		AuthorId:       c.userID,
+		SessionId:      c.sessionID,
+	}
+}
+
 func (c *ClientActor) createComment(context protoactor.Context) *generated.CommentMessage {
 	if len(c.subreddits) == 0 {
 		return nil
@@ -225,11 +321,12 @@ func (c *ClientActor) createComment(context protoactor.Context) *generated.Comme
 
 	comment := &generated.CommentMessage{
 		Id:        utils.GenerateID(),
-		PostId:    utils.GenerateID(), // In real implementation, get actual post ID
-		ParentId:  "",                 // Root level comment
+		PostId:    c.targetPost(),
+		ParentId:  "", // Root level comment
 		AuthorId:  c.userID,
 		Content:   utils.GenerateRandomContent(),
 		CreatedAt: time.Now().Unix(),
+		SessionId: c.sessionID,
 	}
 
 	context.Request(c.enginePID, comment)
@@ -249,23 +346,48 @@ func (c *ClientActor) joinSubreddit(context protoactor.Context) *generated.JoinS
 	join := &generated.JoinSubredditMessage{
 		SubredditId: subredditID,
 		UserId:      c.userID,
+		SessionId:   c.sessionID,
 	}
 
-	context.Request(c.enginePID, join)
+	// Only track the subreddit once the engine has accepted the join
+	result, err := context.RequestFuture(c.enginePID, join, 5*time.Second).Result()
+	if _, ok := result.(*pb.SuccessResponse); err != nil || !ok {
+		c.metrics.RecordError()
+		return nil
+	}
+	if !c.isSubscribed(subredditID) {
+		c.subreddits = append(c.subreddits, subredditID)
+		context.Request(c.enginePID, &pb.SubscribeMessage{
+			Topic:     pb.SubscriptionTopic_TOPIC_SUBREDDIT,
+			TargetId:  subredditID,
+			UserId:    c.userID,
+			SessionId: c.sessionID,
+		})
+	}
 	return join
 }
 
 func (c *ClientActor) vote(context protoactor.Context) *generated.VoteMessage {
 	vote := &generated.VoteMessage{
-		TargetId: utils.GenerateID(), // In real implementation, get actual post/comment ID
-		UserId:   c.userID,
-		IsUpvote: rand.Float32() > 0.3, // 70% chance of upvote
+		TargetId:  c.targetPost(),
+		UserId:    c.userID,
+		IsUpvote:  rand.Float32() > 0.3, // 70% chance of upvote
+		SessionId: c.sessionID,
 	}
 
 	context.Request(c.enginePID, vote)
 	return vote
 }
 
+func (c *ClientActor) isSubscribed(subredditID string) bool {
+	for _, subreddit := range c.subreddits {
+		if subreddit == subredditID {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *ClientActor) isActiveHour(hour int) bool {
 	for _, activeHour := range c.behavior.ActiveHours {
 		if hour == activeHour {
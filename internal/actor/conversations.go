@@ -0,0 +1,215 @@
+package actor
+
+import (
+	"github.com/asynkron/protoactor-go/actor"
+	pb "reddit-clone/api/proto/generated"
+	"reddit-clone/internal/models"
+	"sort"
+	"time"
+)
+
+func (u *UserActor) handleGetMessages(context actor.Context, msg *pb.GetMessagesMessage) {
+	start := time.Now()
+
+	messages, err := u.store.GetMessages(u.userID)
+	if err != nil {
+		u.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	page, next := paginate(messages, func(m *models.DirectMessage) string { return m.ID }, msg.After, msg.Limit)
+
+	response := &pb.MessagesResponse{
+		Messages: make([]*pb.DirectMessageMessage, 0, len(page)),
+		Next:     next,
+	}
+	for _, message := range page {
+		response.Messages = append(response.Messages, directMessage(message))
+	}
+
+	u.metrics.RecordRequest(time.Since(start).Seconds())
+	context.Respond(response)
+}
+
+// visibleMessages returns the messages in a conversation this user has not deleted, oldest first
+func (u *UserActor) visibleMessages(conversationID string) ([]*models.DirectMessage, error) {
+	messages, err := u.store.GetConversation(conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	visible := messages[:0]
+	for _, message := range messages {
+		if message.VisibleTo(u.userID) {
+			visible = append(visible, message)
+		}
+	}
+	return visible, nil
+}
+
+func (u *UserActor) handleGetConversations(context actor.Context, msg *pb.GetConversationsMessage) {
+	start := time.Now()
+
+	conversationIDs, err := u.store.GetConversationIDs(u.userID)
+	if err != nil {
+		u.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	conversations := make([]*pb.ConversationMessage, 0, len(conversationIDs))
+	for _, conversationID := range conversationIDs {
+		messages, err := u.visibleMessages(conversationID)
+		if err != nil || len(messages) == 0 {
+			continue
+		}
+
+		last := messages[len(messages)-1]
+		conversations = append(conversations, &pb.ConversationMessage{
+			Id:          conversationID,
+			OtherUserId: last.OtherParty(u.userID),
+			LastMessage: directMessage(last),
+			UnreadCount: u.unreadIn(messages),
+		})
+	}
+
+	// Most recently active first
+	sort.Slice(conversations, func(i, j int) bool {
+		a, b := conversations[i].LastMessage, conversations[j].LastMessage
+		if a.Timestamp != b.Timestamp {
+			return a.Timestamp > b.Timestamp
+		}
+		return conversations[i].Id < conversations[j].Id
+	})
+
+	page, next := paginate(conversations, func(c *pb.ConversationMessage) string { return c.Id }, msg.After, msg.Limit)
+
+	u.metrics.RecordRequest(time.Since(start).Seconds())
+	context.Respond(&pb.ConversationsResponse{Conversations: page, Next: next})
+}
+
+func (u *UserActor) handleGetConversation(context actor.Context, msg *pb.GetConversationMessage) {
+	start := time.Now()
+
+	conversationID := models.ConversationID(u.userID, msg.OtherUserId)
+	messages, err := u.visibleMessages(conversationID)
+	if err != nil {
+		u.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	// Newest first
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+
+	if msg.MarkRead {
+		u.markRead(context, conversationID, msg.OtherUserId)
+	}
+
+	page, next := paginate(messages, func(m *models.DirectMessage) string { return m.ID }, msg.After, msg.Limit)
+
+	response := &pb.MessagesResponse{
+		Messages: make([]*pb.DirectMessageMessage, 0, len(page)),
+		Next:     next,
+	}
+	for _, message := range page {
+		response.Messages = append(response.Messages, directMessage(message))
+	}
+
+	u.metrics.RecordRequest(time.Since(start).Seconds())
+	context.Respond(response)
+}
+
+func (u *UserActor) handleMarkConversationRead(context actor.Context, msg *pb.MarkConversationReadMessage) {
+	u.markRead(context, models.ConversationID(u.userID, msg.OtherUserId), msg.OtherUserId)
+	context.Respond(&pb.UnreadCountResponse{Notifications: u.unreadNotifications(), Messages: u.unreadMessages()})
+}
+
+// markRead marks everything the other party sent as read and, if anything
+// changed, sends them a read receipt.
+func (u *UserActor) markRead(context actor.Context, conversationID, otherUserID string) {
+	messages, err := u.visibleMessages(conversationID)
+	if err != nil || u.unreadIn(messages) == 0 {
+		return
+	}
+
+	if err := u.store.MarkConversationRead(conversationID, u.userID, time.Now().Unix()); err != nil {
+		u.metrics.RecordError()
+		return
+	}
+
+	context.Send(context.Parent(), &inboxEvent{UserID: otherUserID, Event: &pb.EventMessage{
+		Type:     pb.EventType_EVENT_MESSAGE_READ,
+		TargetId: conversationID,
+	}})
+}
+
+func (u *UserActor) handleDeleteMessage(context actor.Context, msg *pb.DeleteMessageMessage) {
+	if err := u.store.DeleteMessage(msg.MessageId, u.userID); err != nil {
+		u.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error(), Code: pb.ErrorCode_NOT_FOUND})
+		return
+	}
+	context.Respond(&pb.SuccessResponse{Message: "Message deleted successfully"})
+}
+
+func (u *UserActor) handleBlockUser(context actor.Context, msg *pb.BlockUserMessage) {
+	if msg.BlockedUserId == u.userID {
+		u.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: "users cannot block themselves"})
+		return
+	}
+
+	if msg.Unblock {
+		if err := u.store.UnblockUser(u.userID, msg.BlockedUserId); err != nil {
+			u.metrics.RecordError()
+			context.Respond(&pb.ErrorResponse{Error: err.Error(), Code: pb.ErrorCode_NOT_FOUND})
+			return
+		}
+		delete(u.blocked, msg.BlockedUserId)
+		context.Respond(&pb.SuccessResponse{Message: "User unblocked successfully"})
+		return
+	}
+
+	if err := u.store.BlockUser(u.userID, msg.BlockedUserId); err != nil {
+		u.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error(), Code: pb.ErrorCode_NOT_FOUND})
+		return
+	}
+	u.blocked[msg.BlockedUserId] = true
+	context.Respond(&pb.SuccessResponse{Message: "User blocked successfully"})
+}
+
+// unreadIn counts messages sent to this user that have not been read
+func (u *UserActor) unreadIn(messages []*models.DirectMessage) int32 {
+	var unread int32
+	for _, message := range messages {
+		if message.ToID == u.userID && message.ReadAt == 0 {
+			unread++
+		}
+	}
+	return unread
+}
+
+func (u *UserActor) unreadMessages() int32 {
+	messages, err := u.store.GetMessages(u.userID)
+	if err != nil {
+		return 0
+	}
+	return u.unreadIn(messages)
+}
+
+func directMessage(message *models.DirectMessage) *pb.DirectMessageMessage {
+	return &pb.DirectMessageMessage{
+		Id:             message.ID,
+		ConversationId: message.ConversationID,
+		FromId:         message.FromID,
+		ToId:           message.ToID,
+		Content:        message.Content,
+		Timestamp:      message.Timestamp,
+		ReadAt:         message.ReadAt,
+	}
+}
@@ -0,0 +1,96 @@
+package actor
+
+import (
+	"github.com/asynkron/protoactor-go/actor"
+	pb "reddit-clone/api/proto/generated"
+	"reddit-clone/internal/common"
+	"reddit-clone/internal/models"
+	"time"
+)
+
+// handleCrosspost shares an existing post into another subreddit. Crossposts
+// are the sanctioned way to repost, so they skip repost detection but are
+// otherwise treated like new posts in the target subreddit.
+func (e *EngineActor) handleCrosspost(context actor.Context, msg *pb.CrosspostMessage) {
+	start := time.Now()
+
+	if !e.authenticate(context, msg.AuthorId, msg.SessionId) {
+		return
+	}
+	original, err := e.store.GetPost(msg.OriginalPostId)
+	if err != nil || original.Removed || original.Filtered || !e.readable(original.SubredditID, msg.AuthorId) {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: "post not found", Code: pb.ErrorCode_NOT_FOUND})
+		return
+	}
+	// Crossposts of crossposts link straight to the original
+	if original.CrosspostOf != "" {
+		if root, err := e.store.GetPost(original.CrosspostOf); err == nil {
+			original = root
+		}
+	}
+
+	if _, err := e.store.GetSubreddit(msg.SubredditId); err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error(), Code: pb.ErrorCode_NOT_FOUND})
+		return
+	}
+	if origin, err := e.store.GetSubreddit(original.SubredditID); err == nil && origin.Visibility == models.VisibilityPrivate {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: "posts from private subreddits cannot be crossposted", Code: pb.ErrorCode_FORBIDDEN})
+		return
+	}
+	if original.Type == models.PostPoll {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: "polls cannot be crossposted"})
+		return
+	}
+	if msg.SubredditId == original.SubredditID {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: "cannot crosspost into the original subreddit"})
+		return
+	}
+
+	if !e.checkBanned(context, msg.SubredditId, msg.AuthorId) {
+		return
+	}
+	if !e.checkAccess(context, msg.SubredditId, msg.AuthorId, true) {
+		return
+	}
+	if !e.allow(context, msg.AuthorId, common.PostAction, msg.SubredditId) {
+		return
+	}
+
+	title := msg.Title
+	if title == "" {
+		title = original.Title
+	}
+	// Crossposts carry the original's fingerprint, so later reposts of the same
+	// content into the target subreddit are still caught
+	post := &models.Post{
+		ID:          msg.Id,
+		SubredditID: msg.SubredditId,
+		AuthorID:    msg.AuthorId,
+		Title:       title,
+		Content:     original.Content,
+		Created:     time.Now().Unix(),
+		Votes:       make(map[string]bool),
+		CrosspostOf: original.ID,
+		Type:        original.Type,
+		URL:         original.URL,
+		Domain:      original.Domain,
+		ImageIDs:    original.ImageIDs,
+		ContentHash: original.ContentHash,
+		SimHash:     original.SimHash,
+	}
+
+	// The repost check is the only step of a new post skipped here
+	if err := e.createPost(context, post); err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	e.metrics.RecordRequest(time.Since(start).Seconds())
+	context.Respond(&pb.SuccessResponse{Message: "Crosspost created successfully"})
+}
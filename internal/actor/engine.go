@@ -2,28 +2,83 @@ package actor
 
 import (
 	"github.com/asynkron/protoactor-go/actor"
+	"github.com/asynkron/protoactor-go/scheduler"
 	pb "reddit-clone/api/proto/generated"
+	"reddit-clone/internal/automod"
+	"reddit-clone/internal/common"
+	"reddit-clone/internal/dedup"
 	"reddit-clone/internal/models"
+	"reddit-clone/internal/ratelimit"
+	"reddit-clone/internal/recommend"
+	"reddit-clone/internal/search"
 	"reddit-clone/internal/store"
+	"reddit-clone/pkg/config"
 	"reddit-clone/pkg/metrics"
-	"sort"
 	"time"
 )
 
 type EngineActor struct {
-	store   store.Store
-	metrics *metrics.RedditMetrics
+	store         store.Store
+	metrics       *metrics.RedditMetrics
+	config        *config.Config
+	users         map[string]*actor.PID // user_id -> active user actor
+	limiter       *ratelimit.Limiter
+	reposts       *dedup.Detector
+	search        *search.Index
+	subscriptions *subscriptions
+	leaderboards  *leaderboards
+	trends        *trends
+	recommender   *recommend.Recommender
+	forYou        *forYou
+	automod       map[string]automod.Rules // subreddit_id -> compiled AutoModerator rules
+	stopTick      scheduler.CancelFunc
 }
 
-func NewEngineActor(store store.Store, metrics *metrics.RedditMetrics) *EngineActor {
+func NewEngineActor(store store.Store, metrics *metrics.RedditMetrics, config *config.Config) *EngineActor {
 	return &EngineActor{
-		store:   store,
-		metrics: metrics,
+		store:         store,
+		metrics:       metrics,
+		config:        config,
+		users:         make(map[string]*actor.PID),
+		limiter:       ratelimit.NewLimiter(),
+		reposts:       dedup.NewDetector(config.Dedup.Window, config.Dedup.MaxDistance),
+		search:        search.NewIndex(),
+		subscriptions: newSubscriptions(),
+		leaderboards:  newLeaderboards(),
+		trends:        newTrends(config.Trending),
+		recommender:   recommend.NewRecommender(config.Recommendations.VoteWeight),
+		forYou:        newForYou(),
+		automod:       make(map[string]automod.Rules),
 	}
 }
 
+// NewEngineProps builds the props for the engine actor. A fresh EngineActor is
+// produced on every (re)start so no state survives a crash except what is
+// rebuilt from the store; children are supervised by the same strategy.
+func NewEngineProps(store store.Store, metrics *metrics.RedditMetrics, config *config.Config) *actor.Props {
+	strategy := NewSupervisor(metrics)
+	return actor.PropsFromProducer(func() actor.Actor {
+		return NewEngineActor(store, metrics, config)
+	}, actor.WithSupervisor(strategy), actor.WithGuardian(strategy))
+}
+
 func (e *EngineActor) Receive(context actor.Context) {
 	switch msg := context.Message().(type) {
+	case *actor.Started:
+		e.recoverState()
+		e.restoreSubscriptions(context)
+		e.startTicker(context)
+	case *actor.Restarting:
+		e.stopTicker()
+		e.metrics.RecordActorRestart("engine")
+	case *actor.Stopping:
+		e.stopTicker()
+	case *tick:
+		e.handleTick(context)
+	case *postModelTrained:
+		e.handlePostModelTrained(msg)
+	case *itemSaved:
+		e.handleItemSaved(msg)
 	case *pb.PingMessage:
 		context.Respond(&pb.PongMessage{})
 	case *pb.UserMessage:
@@ -34,6 +89,30 @@ func (e *EngineActor) Receive(context actor.Context) {
 		e.handleJoinSubredditMessage(context, msg)
 	case *pb.PostMessage:
 		e.handlePostMessage(context, msg)
+	case *pb.GetScheduledPostsMessage:
+		e.handleGetScheduledPosts(context, msg)
+	case *pb.EditScheduledPostMessage:
+		e.handleEditScheduledPost(context, msg)
+	case *pb.CancelScheduledPostMessage:
+		e.handleCancelScheduledPost(context, msg)
+	case *pb.StickyPostMessage:
+		e.handleStickyPost(context, msg)
+	case *pb.LockPostMessage:
+		e.handleLockPost(context, msg)
+	case *pb.SetAutoModConfigMessage:
+		e.handleSetAutoModConfig(context, msg)
+	case *pb.GetAutoModConfigMessage:
+		e.handleGetAutoModConfig(context, msg)
+	case *pb.CrosspostMessage:
+		e.handleCrosspost(context, msg)
+	case *pb.PollVoteMessage:
+		e.handlePollVote(context, msg)
+	case *pb.OtherDiscussionsMessage:
+		e.handleOtherDiscussions(context, msg)
+	case *pb.UploadImageMessage:
+		e.handleUploadImage(context, msg)
+	case *pb.GetImageMessage:
+		e.handleGetImage(context, msg)
 	case *pb.CommentMessage:
 		e.handleCommentMessage(context, msg)
 	case *pb.VoteMessage:
@@ -42,24 +121,211 @@ func (e *EngineActor) Receive(context actor.Context) {
 		e.handleDirectMessage(context, msg)
 	case *pb.GetFeedMessage:
 		e.handleGetFeed(context, msg)
+	case *pb.CreateMultiredditMessage:
+		e.handleCreateMultireddit(context, msg)
+	case *pb.UpdateMultiredditMessage:
+		e.handleUpdateMultireddit(context, msg)
+	case *pb.DeleteMultiredditMessage:
+		e.handleDeleteMultireddit(context, msg)
+	case *pb.GetMultiredditMessage:
+		e.handleGetMultireddit(context, msg)
+	case *pb.GetMultiredditsMessage:
+		e.handleGetMultireddits(context, msg)
+	case *pb.CopyMultiredditMessage:
+		e.handleCopyMultireddit(context, msg)
 	case *pb.GetCommentsMessage:
 		e.handleGetComments(context, msg)
+	case *pb.GetPostMessage:
+		e.handleGetPost(context, msg)
+	case *pb.GetSubredditPostsMessage:
+		e.handleGetSubredditPosts(context, msg)
+	case *pb.SetFlairTemplatesMessage:
+		e.handleSetFlairTemplates(context, msg)
+	case *pb.GetFlairTemplatesMessage:
+		e.handleGetFlairTemplates(context, msg)
+	case *pb.AssignPostFlairMessage:
+		e.handleAssignPostFlair(context, msg)
+	case *pb.AssignUserFlairMessage:
+		e.handleAssignUserFlair(context, msg)
+	case *pb.SetVisibilityMessage:
+		e.handleSetVisibility(context, msg)
+	case *pb.GetJoinRequestsMessage:
+		e.handleGetJoinRequests(context, msg)
+	case *pb.ResolveJoinRequestMessage:
+		e.handleResolveJoinRequest(context, msg)
+	case *pb.InviteMessage:
+		e.handleInvite(context, msg)
+	case *pb.GetUserProfileMessage:
+		e.handleGetUserProfile(context, msg)
+	case *pb.GetLeaderboardMessage:
+		e.handleGetLeaderboard(context, msg)
+	case *pb.GetTrendingMessage:
+		e.handleGetTrending(context, msg)
+	case *pb.GetRecommendationsMessage:
+		e.handleGetRecommendations(context, msg)
+	case *pb.GetMessagesMessage:
+		e.forwardAuthenticated(context, msg.UserId, msg.SessionId)
+	case *pb.GetConversationsMessage:
+		e.forwardAuthenticated(context, msg.UserId, msg.SessionId)
+	case *pb.GetConversationMessage:
+		e.forwardAuthenticated(context, msg.UserId, msg.SessionId)
+	case *pb.MarkConversationReadMessage:
+		e.forwardAuthenticated(context, msg.UserId, msg.SessionId)
+	case *pb.DeleteMessageMessage:
+		e.forwardAuthenticated(context, msg.UserId, msg.SessionId)
+	case *pb.BlockUserMessage:
+		e.forwardAuthenticated(context, msg.UserId, msg.SessionId)
+	case *pb.SaveMessage:
+		e.handleSave(context, msg)
+	case *pb.GetSavedMessage:
+		e.handleGetSaved(context, msg)
+	case *pb.GetUserMessage:
+		e.forwardToUser(context, msg.UserId)
+	case *pb.LoginMessage:
+		e.forwardToUser(context, msg.UserId)
+	case *pb.LogoutMessage:
+		e.forwardToUser(context, msg.UserId)
+	case *pb.GetNotificationsMessage:
+		e.forwardAuthenticated(context, msg.UserId, msg.SessionId)
+	case *pb.MarkNotificationsReadMessage:
+		e.forwardAuthenticated(context, msg.UserId, msg.SessionId)
+	case *pb.GetUnreadCountMessage:
+		e.forwardAuthenticated(context, msg.UserId, msg.SessionId)
+	case *pb.ModerateContentMessage:
+		e.handleModerateContent(context, msg)
+	case *pb.BanUserMessage:
+		e.handleBanUser(context, msg)
+	case *pb.UnbanUserMessage:
+		e.handleUnbanUser(context, msg)
+	case *pb.ModeratorMessage:
+		e.handleModeratorMessage(context, msg)
+	case *pb.GetModLogMessage:
+		e.handleGetModLog(context, msg)
+	case *pb.SetReportReasonsMessage:
+		e.handleSetReportReasons(context, msg)
+	case *pb.ReportMessage:
+		e.handleReport(context, msg)
+	case *pb.GetModQueueMessage:
+		e.handleGetModQueue(context, msg)
+	case *pb.ResolveReportMessage:
+		e.handleResolveReport(context, msg)
+	case *pb.SearchMessage:
+		e.handleSearch(context, msg)
+	case *pb.SubscribeMessage:
+		e.handleSubscribe(context, msg)
+	case *pb.UnsubscribeAllMessage:
+		e.handleUnsubscribeAll(context)
+	case *inboxEvent:
+		e.publish(context, pb.SubscriptionTopic_TOPIC_INBOX, msg.UserID, msg.Event)
+	case *passivate:
+		e.handlePassivate(context, msg)
+	case *actor.Terminated:
+		e.handleTerminated(context, msg)
 	}
 }
 
-func (e *EngineActor) handleJoinSubredditMessage(context actor.Context, msg *pb.JoinSubredditMessage) {
-	start := time.Now()
+// recoverState rebuilds the engine's derived state from the store.
+func (e *EngineActor) recoverState() {
+	subreddits, err := e.store.GetSubreddits()
+	if err != nil {
+		e.metrics.RecordError()
+		return
+	}
+	for _, subreddit := range subreddits {
+		e.metrics.UpdateSubredditMembers(subreddit.ID, float64(len(subreddit.Members)))
+	}
+
+	posts, err := e.store.GetPostsSince(time.Now().Add(-e.config.Dedup.Window).Unix())
+	if err != nil {
+		e.metrics.RecordError()
+		return
+	}
+	for _, post := range posts {
+		e.indexPost(post)
+	}
+
+	if err := e.rebuildSearchIndex(); err != nil {
+		e.metrics.RecordError()
+	}
+	if err := e.rebuildLeaderboards(subreddits); err != nil {
+		e.metrics.RecordError()
+	}
+	if err := e.rebuildTrends(); err != nil {
+		e.metrics.RecordError()
+	}
+	if err := e.rebuildRecommendations(subreddits); err != nil {
+		e.metrics.RecordError()
+	}
+	if err := e.rebuildForYou(); err != nil {
+		e.metrics.RecordError()
+	}
+	e.rebuildAutoMod(subreddits)
+}
 
-	err := e.store.JoinSubreddit(msg.SubredditId, msg.UserId)
+// userActor returns the PID of the user's actor, activating it if needed.
+func (e *EngineActor) userActor(context actor.Context, userID string) (*actor.PID, error) {
+	if pid, exists := e.users[userID]; exists {
+		return pid, nil
+	}
+
+	if _, err := e.store.GetUser(userID); err != nil {
+		return nil, err
+	}
+
+	props := actor.PropsFromProducer(func() actor.Actor {
+		return NewUserActor(userID, e.store, e.metrics)
+	})
+	pid := context.SpawnPrefix(props, "user-"+userID)
+	e.users[userID] = pid
+	return pid, nil
+}
+
+// forwardToUser hands the current request to the user's actor, which responds to the original sender.
+func (e *EngineActor) forwardToUser(context actor.Context, userID string) {
+	pid, err := e.userActor(context, userID)
 	if err != nil {
 		e.metrics.RecordError()
 		context.Respond(&pb.ErrorResponse{Error: err.Error()})
 		return
 	}
+	context.Forward(pid)
+}
 
-	e.metrics.UpdateSubredditMembers(msg.SubredditId, 1)
-	e.metrics.RecordRequest(time.Since(start).Seconds())
-	context.Respond(&pb.SuccessResponse{Message: "Joined subreddit successfully"})
+// forwardAuthenticated checks the caller's session before forwarding to the user's actor.
+func (e *EngineActor) forwardAuthenticated(context actor.Context, userID, sessionID string) {
+	if e.authenticate(context, userID, sessionID) {
+		e.forwardToUser(context, userID)
+	}
+}
+
+// notifyUser delivers an internal update to the user's actor only if it is currently active.
+func (e *EngineActor) notifyUser(context actor.Context, userID string, msg interface{}) {
+	if pid, exists := e.users[userID]; exists {
+		context.Send(pid, msg)
+	}
+}
+
+func (e *EngineActor) handlePassivate(context actor.Context, msg *passivate) {
+	pid, exists := e.users[msg.UserID]
+	if !exists || !pid.Equal(context.Sender()) {
+		return
+	}
+
+	// Poison rather than Stop so requests already in the mailbox are still served.
+	delete(e.users, msg.UserID)
+	context.Poison(pid)
+}
+
+func (e *EngineActor) handleTerminated(context actor.Context, msg *actor.Terminated) {
+	for userID, pid := range e.users {
+		if pid.Equal(msg.Who) {
+			delete(e.users, userID)
+			return
+		}
+	}
+
+	// A watched subscriber stopped
+	e.unsubscribeAll(context, msg.Who)
 }
 
 func (e *EngineActor) handleUserMessage(context actor.Context, msg *pb.UserMessage) {
@@ -86,13 +352,25 @@ func (e *EngineActor) handleUserMessage(context actor.Context, msg *pb.UserMessa
 func (e *EngineActor) handleSubredditMessage(context actor.Context, msg *pb.SubredditMessage) {
 	start := time.Now()
 
+	if !e.authenticate(context, msg.CreatorId, msg.SessionId) {
+		return
+	}
+	if !e.allow(context, msg.CreatorId, common.SubredditAction, "") {
+		return
+	}
+
 	subreddit := &models.Subreddit{
-		ID:          msg.Id,
-		Name:        msg.Name,
-		Description: msg.Description,
-		CreatorID:   msg.CreatorId,
-		Members:     make(map[string]bool),
-		Created:     time.Now().Unix(),
+		ID:            msg.Id,
+		Name:          msg.Name,
+		Description:   msg.Description,
+		CreatorID:     msg.CreatorId,
+		Members:       make(map[string]bool),
+		Created:       time.Now().Unix(),
+		RepostPolicy:  models.RepostPolicy(msg.RepostPolicy),
+		Moderators:    []string{msg.CreatorId},
+		Bans:          make(map[string]*models.Ban),
+		ReportReasons: msg.ReportReasons,
+		Visibility:    models.Visibility(msg.Visibility),
 	}
 
 	err := e.store.CreateSubreddit(subreddit)
@@ -102,6 +380,8 @@ func (e *EngineActor) handleSubredditMessage(context actor.Context, msg *pb.Subr
 		return
 	}
 
+	e.indexSubredditText(subreddit)
+
 	e.metrics.UpdateSubredditMembers(subreddit.Name, 1)
 	e.metrics.RecordRequest(time.Since(start).Seconds())
 	context.Respond(&pb.SuccessResponse{Message: "Subreddit created successfully"})
@@ -110,6 +390,24 @@ func (e *EngineActor) handleSubredditMessage(context actor.Context, msg *pb.Subr
 func (e *EngineActor) handlePostMessage(context actor.Context, msg *pb.PostMessage) {
 	start := time.Now()
 
+	if !e.authenticate(context, msg.AuthorId, msg.SessionId) {
+		return
+	}
+	if _, err := e.store.GetSubreddit(msg.SubredditId); err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error(), Code: pb.ErrorCode_NOT_FOUND})
+		return
+	}
+	if !e.checkBanned(context, msg.SubredditId, msg.AuthorId) {
+		return
+	}
+	if !e.checkAccess(context, msg.SubredditId, msg.AuthorId, true) {
+		return
+	}
+	if !e.allow(context, msg.AuthorId, common.PostAction, msg.SubredditId) {
+		return
+	}
+
 	post := &models.Post{
 		ID:          msg.Id,
 		SubredditID: msg.SubredditId,
@@ -119,22 +417,94 @@ func (e *EngineActor) handlePostMessage(context actor.Context, msg *pb.PostMessa
 		Created:     time.Now().Unix(),
 		Votes:       make(map[string]bool),
 	}
+	if msg.PublishAt > post.Created {
+		post.Created = msg.PublishAt // polls run from when the post goes live
+	}
 
-	err := e.store.CreatePost(post)
-	if err != nil {
+	if err := e.preparePost(post, msg); err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error()})
+		return
+	}
+	if msg.Flair != nil && msg.Flair.Id != "" {
+		subreddit, err := e.store.GetSubreddit(post.SubredditID)
+		if err == nil {
+			post.Flair, err = chooseFlair(subreddit, subreddit.PostFlairs, msg.Flair.Id, post.AuthorID)
+		}
+		if err != nil {
+			e.metrics.RecordError()
+			context.Respond(&pb.ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+	if msg.PublishAt > start.Unix() || msg.RepeatEverySeconds > 0 {
+		e.schedulePost(context, post, msg, start)
+		return
+	}
+	if !e.checkRepost(context, post) {
+		return
+	}
+
+	if err := e.createPost(context, post); err != nil {
 		e.metrics.RecordError()
 		context.Respond(&pb.ErrorResponse{Error: err.Error()})
 		return
 	}
 
-	e.metrics.PostsCreated.Inc()
 	e.metrics.RecordRequest(time.Since(start).Seconds())
 	context.Respond(&pb.SuccessResponse{Message: "Post created successfully"})
 }
 
+// createPost stores a checked post and announces it
+func (e *EngineActor) createPost(context actor.Context, post *models.Post) error {
+	if err := e.store.CreatePost(post); err != nil {
+		return err
+	}
+	created := time.Unix(post.Created, 0)
+	e.indexPost(post)
+	e.indexPostText(post)
+	e.leaderboards.posters.Add(post.AuthorID, 1, created)
+	e.trends.recordActivity(post.SubredditID, "", created)
+	e.forYou.interactions.Add(post.AuthorID, post.ID, post.Created, 0)
+	e.metrics.PostsCreated.Inc()
+	if e.autoModeratePost(context, post) {
+		return nil
+	}
+
+	e.notifyPost(context, post)
+	e.publish(context, pb.SubscriptionTopic_TOPIC_SUBREDDIT, post.SubredditID, &pb.EventMessage{
+		Type: pb.EventType_EVENT_NEW_POST,
+		Post: postMessage(post),
+	})
+	return nil
+}
+
 func (e *EngineActor) handleCommentMessage(context actor.Context, msg *pb.CommentMessage) {
 	start := time.Now()
 
+	if !e.authenticate(context, msg.AuthorId, msg.SessionId) {
+		return
+	}
+	post, err := e.store.GetPost(msg.PostId)
+	if err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error(), Code: pb.ErrorCode_NOT_FOUND})
+		return
+	}
+	subredditID := post.SubredditID
+	if !e.checkBanned(context, subredditID, msg.AuthorId) {
+		return
+	}
+	if !e.checkAccess(context, subredditID, msg.AuthorId, false) {
+		return
+	}
+	if !e.checkLocked(context, post, msg.AuthorId) {
+		return
+	}
+	if !e.allow(context, msg.AuthorId, common.CommentAction, subredditID) {
+		return
+	}
+
 	comment := &models.Comment{
 		ID:       msg.Id,
 		PostID:   msg.PostId,
@@ -144,13 +514,27 @@ func (e *EngineActor) handleCommentMessage(context actor.Context, msg *pb.Commen
 		Created:  time.Now().Unix(),
 	}
 
-	err := e.store.AddComment(comment)
+	err = e.store.AddComment(comment)
 	if err != nil {
 		e.metrics.RecordError()
 		context.Respond(&pb.ErrorResponse{Error: err.Error()})
 		return
 	}
 
+	e.indexCommentText(comment, subredditID)
+	e.leaderboards.commenters.Add(comment.AuthorID, 1, start)
+	e.trends.recordActivity(subredditID, comment.PostID, start)
+	if post != nil {
+		e.forYou.interactions.Add(comment.AuthorID, post.ID, post.Created, e.config.ForYou.CommentWeight)
+	}
+	if target, err := e.findTarget(comment.ID, models.TargetComment); err != nil || !e.autoModerate(context, target, 0) {
+		e.notifyComment(context, comment, post)
+		e.publish(context, pb.SubscriptionTopic_TOPIC_POST, comment.PostID, &pb.EventMessage{
+			Type:    pb.EventType_EVENT_NEW_COMMENT,
+			Comment: commentMessage(comment),
+		})
+	}
+
 	e.metrics.CommentsCreated.Inc()
 	e.metrics.RecordRequest(time.Since(start).Seconds())
 	context.Respond(&pb.SuccessResponse{Message: "Comment created successfully"})
@@ -159,85 +543,190 @@ func (e *EngineActor) handleCommentMessage(context actor.Context, msg *pb.Commen
 func (e *EngineActor) handleVoteMessage(context actor.Context, msg *pb.VoteMessage) {
 	start := time.Now()
 
-	err := e.store.Vote(msg.TargetId, msg.UserId, msg.IsUpvote)
+	if !e.authenticate(context, msg.UserId, msg.SessionId) {
+		return
+	}
+	subredditID := e.targetSubreddit(msg.TargetId)
+	if subredditID == "" {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: "vote target not found", Code: pb.ErrorCode_NOT_FOUND})
+		return
+	}
+	if !e.checkBanned(context, subredditID, msg.UserId) {
+		return
+	}
+	if !e.checkAccess(context, subredditID, msg.UserId, false) {
+		return
+	}
+	if !e.allow(context, msg.UserId, common.VoteAction, subredditID) {
+		return
+	}
+
+	// Votes can be changed or cast again, so signals are moved by the
+	// difference from the user's previous vote
+	previous, err := e.store.GetVotes(msg.TargetId)
+	if err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error()})
+		return
+	}
+	wasUpvote, voted := previous[msg.UserId]
+
+	err = e.store.Vote(msg.TargetId, msg.UserId, msg.IsUpvote)
 	if err != nil {
 		e.metrics.RecordError()
 		context.Respond(&pb.ErrorResponse{Error: err.Error()})
 		return
 	}
 
+	change := voteValue(msg.IsUpvote)
+	if voted {
+		change -= voteValue(wasUpvote)
+	}
+	if subredditID != "" && change != 0 {
+		e.recommender.Vote(msg.UserId, subredditID, change)
+	}
+
+	// Credit the author through their user actor, which owns karma
+	delta := int32(change)
+	if post, err := e.store.GetPost(msg.TargetId); err == nil {
+		if pid, err := e.userActor(context, post.AuthorID); err == nil && delta != 0 {
+			context.Send(pid, &karmaDelta{Delta: delta})
+		}
+		if delta != 0 {
+			e.leaderboards.addKarma(subredditID, post.AuthorID, int64(delta), start)
+		}
+		e.trends.recordActivity(subredditID, post.ID, start)
+		weight := voteWeight(e.config.ForYou.UpvoteWeight, msg.IsUpvote)
+		if voted {
+			weight -= voteWeight(e.config.ForYou.UpvoteWeight, wasUpvote)
+		}
+		e.forYou.interactions.Add(msg.UserId, post.ID, post.Created, weight)
+
+		e.publish(context, pb.SubscriptionTopic_TOPIC_POST, post.ID, &pb.EventMessage{
+			Type:     pb.EventType_EVENT_VOTE,
+			TargetId: post.ID,
+			Karma:    post.Karma,
+		})
+	} else if comment, err := e.store.GetComment(msg.TargetId); err == nil {
+		if pid, err := e.userActor(context, comment.AuthorID); err == nil && delta != 0 {
+			context.Send(pid, &karmaDelta{Delta: delta})
+		}
+		if delta != 0 {
+			e.leaderboards.addKarma(subredditID, comment.AuthorID, int64(delta), start)
+		}
+		e.trends.recordActivity(subredditID, comment.PostID, start)
+
+		e.publish(context, pb.SubscriptionTopic_TOPIC_POST, comment.PostID, &pb.EventMessage{
+			Type:     pb.EventType_EVENT_VOTE,
+			TargetId: comment.ID,
+			Karma:    comment.Karma,
+		})
+	}
+
 	e.metrics.VotesRecorded.Inc()
 	e.metrics.RecordRequest(time.Since(start).Seconds())
 	context.Respond(&pb.SuccessResponse{Message: "Vote recorded successfully"})
 }
 
 func (e *EngineActor) handleDirectMessage(context actor.Context, msg *pb.DirectMessageMessage) {
-	start := time.Now()
-
-	message := &models.DirectMessage{
-		ID:        msg.GetId(),      // Use GetId() method
-		FromID:    msg.GetFromId(),  // Use GetFromId() method
-		ToID:      msg.GetToId(),    // Use GetToId() method
-		Content:   msg.GetContent(), // Use GetContent() method
-		Timestamp: time.Now().Unix(),
+	if msg.GetFromId() == msg.GetToId() {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: "cannot message yourself"})
+		return
 	}
-
-	err := e.store.SendMessage(message)
-	if err != nil {
+	if _, err := e.store.GetUser(msg.GetFromId()); err != nil {
 		e.metrics.RecordError()
-		context.Respond(&pb.ErrorResponse{Error: err.Error()})
+		context.Respond(&pb.ErrorResponse{Error: err.Error(), Code: pb.ErrorCode_NOT_FOUND})
+		return
+	}
+	if !e.authenticate(context, msg.GetFromId(), msg.GetSessionId()) {
+		return
+	}
+	if !e.allow(context, msg.GetFromId(), common.MessageAction, "") {
 		return
 	}
 
-	e.metrics.RecordRequest(time.Since(start).Seconds())
-	context.Respond(&pb.SuccessResponse{Message: "Message sent successfully"})
+	// Delivery is owned by the recipient's user actor
+	e.forwardToUser(context, msg.GetToId())
 }
 
 func (e *EngineActor) handleGetFeed(context actor.Context, msg *pb.GetFeedMessage) {
 	start := time.Now()
 
+	subredditIDs := msg.SubredditIds
+	var within map[string]bool // restricts the for-you feed to a multireddit
+	if msg.MultiredditId != "" {
+		multireddit := e.viewableMultireddit(context, msg.MultiredditId, msg.UserId)
+		if multireddit == nil {
+			return
+		}
+		subredditIDs = multireddit.SubredditIDs
+		within = make(map[string]bool, len(subredditIDs))
+		for _, subredditID := range subredditIDs {
+			within[subredditID] = true
+		}
+	}
+
+	if msg.Mode == pb.FeedMode_FEED_FOR_YOU {
+		e.handleGetForYouFeed(context, msg, within, start)
+		return
+	}
+
 	// Get posts from subscribed subreddits
 	var feed []*models.Post
-	for _, subredditID := range msg.SubredditIds {
+	for _, subredditID := range subredditIDs {
+		if !e.readable(subredditID, msg.UserId) {
+			continue
+		}
 		posts, err := e.store.GetSubredditPosts(subredditID)
 		if err != nil {
 			e.metrics.RecordError()
 			context.Respond(&pb.ErrorResponse{Error: err.Error()})
 			return
 		}
-		feed = append(feed, posts...)
+		for _, post := range posts {
+			if !post.Removed && !post.Filtered && hasFlair(post, msg.FlairId) {
+				feed = append(feed, post)
+			}
+		}
 	}
 
-	// Sort by creation time and karma
-	sort.Slice(feed, func(i, j int) bool {
-		scoreI := float64(feed[i].Karma) / time.Since(time.Unix(feed[i].Created, 0)).Hours()
-		scoreJ := float64(feed[j].Karma) / time.Since(time.Unix(feed[j].Created, 0)).Hours()
-		return scoreI > scoreJ
-	})
-
-	// Convert to proto message
-	response := &pb.FeedResponse{
-		Posts: make([]*pb.PostMessage, 0, len(feed)),
-	}
-	for _, post := range feed {
-		response.Posts = append(response.Posts, &pb.PostMessage{
-			Id:          post.ID,
-			SubredditId: post.SubredditID,
-			AuthorId:    post.AuthorID,
-			Title:       post.Title,
-			Content:     post.Content,
-			CreatedAt:   post.Created,
-			IsRepost:    false,
-		})
+	// Sort by creation time and karma; a single subreddit's view keeps its stickies on top
+	sortHot(feed, start)
+	if len(subredditIDs) == 1 {
+		feed = e.pinStickies(subredditIDs[0], feed)
 	}
 
 	e.metrics.RecordRequest(time.Since(start).Seconds())
+	e.respondFeed(context, msg.UserId, feed, msg.After, msg.Limit)
+}
+
+// respondFeed responds with a page of posts as seen by userID
+func (e *EngineActor) respondFeed(context actor.Context, userID string, feed []*models.Post, after string, limit int32) {
+	page, next := paginate(feed, func(post *models.Post) string { return post.ID }, after, limit)
+
+	saved := e.savedSet(userID)
+	response := &pb.FeedResponse{
+		Posts: make([]*pb.PostMessage, 0, len(page)),
+		Next:  next,
+	}
+	for _, post := range page {
+		protoPost := postMessage(post)
+		protoPost.Saved = saved[post.ID]
+		protoPost.AuthorFlair = e.authorFlair(post)
+		response.Posts = append(response.Posts, protoPost)
+	}
 	context.Respond(response)
 }
 
 func (e *EngineActor) handleGetComments(context actor.Context, msg *pb.GetCommentsMessage) {
 	start := time.Now()
 
+	if !e.checkAccess(context, e.targetSubreddit(msg.PostId), msg.UserId, false) {
+		return
+	}
+
 	comments, err := e.store.GetComments(msg.PostId)
 	if err != nil {
 		e.metrics.RecordError()
@@ -265,25 +754,78 @@ func (e *EngineActor) handleGetComments(context actor.Context, msg *pb.GetCommen
 		Comments: make([]*pb.CommentMessage, 0, len(comments)),
 	}
 
-	// Helper function to convert comment tree to proto
-	var convertComment func(*models.Comment) *pb.CommentMessage
-	convertComment = func(comment *models.Comment) *pb.CommentMessage {
-		protoComment := &pb.CommentMessage{
-			Id:        comment.ID,
-			PostId:    comment.PostID,
-			ParentId:  comment.ParentID,
-			AuthorId:  comment.AuthorID,
-			Content:   comment.Content,
-			CreatedAt: comment.Created,
-		}
-		return protoComment
-	}
-
 	// Convert all comments starting from root
+	saved := e.savedSet(msg.UserId)
 	for _, rootComment := range rootComments {
-		response.Comments = append(response.Comments, convertComment(rootComment))
+		protoComment := commentMessage(rootComment)
+		protoComment.Saved = saved[rootComment.ID]
+		response.Comments = append(response.Comments, protoComment)
 	}
 
 	e.metrics.RecordRequest(time.Since(start).Seconds())
 	context.Respond(response)
 }
+
+func (e *EngineActor) handleGetPost(context actor.Context, msg *pb.GetPostMessage) {
+	start := time.Now()
+
+	post, err := e.store.GetPost(msg.PostId)
+	if err != nil || post.Removed || post.Filtered || !e.readable(post.SubredditID, msg.UserId) {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: "post not found", Code: pb.ErrorCode_NOT_FOUND})
+		return
+	}
+
+	response := postMessage(post)
+	response.Saved = e.savedSet(msg.UserId)[post.ID]
+	response.AuthorFlair = e.authorFlair(post)
+
+	e.metrics.RecordRequest(time.Since(start).Seconds())
+	context.Respond(response)
+}
+
+func postMessage(post *models.Post) *pb.PostMessage {
+	protoPost := &pb.PostMessage{
+		Id:          post.ID,
+		SubredditId: post.SubredditID,
+		AuthorId:    post.AuthorID,
+		Title:       post.Title,
+		Content:     post.Content,
+		CreatedAt:   post.Created,
+		IsRepost:    post.IsRepost,
+		DuplicateOf: post.DuplicateOf,
+		CrosspostOf: post.CrosspostOf,
+		Crossposts:  post.Crossposts,
+		Type:        pb.PostType(post.Type),
+		Url:         post.URL,
+		Domain:      post.Domain,
+		ImageIds:    post.ImageIDs,
+		Sticky:      post.Sticky,
+		Locked:      post.Locked,
+	}
+	if post.Poll != nil {
+		protoPost.Poll = pollMessage(post.Poll)
+	}
+	if post.Flair != nil {
+		protoPost.Flair = flairMessage(post.Flair)
+	}
+	return protoPost
+}
+
+func commentMessage(comment *models.Comment) *pb.CommentMessage {
+	protoComment := &pb.CommentMessage{
+		Id:        comment.ID,
+		PostId:    comment.PostID,
+		ParentId:  comment.ParentID,
+		AuthorId:  comment.AuthorID,
+		Content:   comment.Content,
+		CreatedAt: comment.Created,
+		Karma:     comment.Karma,
+	}
+	// Keep removed comments as placeholders so the thread stays intact
+	if comment.Removed || comment.Filtered {
+		protoComment.AuthorId = ""
+		protoComment.Content = "[removed]"
+	}
+	return protoComment
+}
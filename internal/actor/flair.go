@@ -0,0 +1,303 @@
+package actor
+
+import (
+	"errors"
+	"fmt"
+	"github.com/asynkron/protoactor-go/actor"
+	pb "reddit-clone/api/proto/generated"
+	"reddit-clone/internal/models"
+	"reddit-clone/pkg/utils"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	maxFlairs          = 50
+	maxFlairTextLength = 64
+)
+
+var flairColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// flairTemplates validates templates from a request, assigning IDs to new ones
+func flairTemplates(msgs []*pb.FlairMessage) ([]*models.Flair, error) {
+	if len(msgs) > maxFlairs {
+		return nil, fmt.Errorf("at most %d flairs are allowed", maxFlairs)
+	}
+
+	flairs := make([]*models.Flair, 0, len(msgs))
+	seen := make(map[string]bool, len(msgs))
+	for _, msg := range msgs {
+		text := strings.TrimSpace(msg.Text)
+		if text == "" || len(text) > maxFlairTextLength {
+			return nil, fmt.Errorf("flair text must be 1 to %d characters", maxFlairTextLength)
+		}
+		if msg.Color != "" && !flairColorPattern.MatchString(msg.Color) {
+			return nil, errors.New("flair colour must look like #rrggbb")
+		}
+
+		id := msg.Id
+		if id == "" {
+			id = utils.GenerateID()
+		}
+		if seen[id] {
+			return nil, errors.New("duplicate flair id " + id)
+		}
+		seen[id] = true
+
+		flairs = append(flairs, &models.Flair{
+			ID:      id,
+			Text:    text,
+			Color:   strings.ToLower(msg.Color),
+			ModOnly: msg.ModOnly,
+		})
+	}
+	return flairs, nil
+}
+
+func (e *EngineActor) handleSetFlairTemplates(context actor.Context, msg *pb.SetFlairTemplatesMessage) {
+	start := time.Now()
+
+	if e.requireModerator(context, msg.SubredditId, msg.ModeratorId, msg.SessionId) == nil {
+		return
+	}
+
+	postFlairs, err := flairTemplates(msg.PostFlairs)
+	if err == nil {
+		var userFlairs []*models.Flair
+		userFlairs, err = flairTemplates(msg.UserFlairs)
+		if err == nil {
+			err = e.store.SetFlairTemplates(msg.SubredditId, postFlairs, userFlairs)
+		}
+	}
+	if err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	e.logModAction(msg.SubredditId, msg.ModeratorId, models.ModRuleChange, msg.SubredditId,
+		fmt.Sprintf("flair templates: %d post, %d user", len(msg.PostFlairs), len(msg.UserFlairs)))
+
+	e.metrics.RecordRequest(time.Since(start).Seconds())
+	context.Respond(&pb.SuccessResponse{Message: "Flair templates updated successfully"})
+}
+
+func (e *EngineActor) handleGetFlairTemplates(context actor.Context, msg *pb.GetFlairTemplatesMessage) {
+	subreddit, err := e.store.GetSubreddit(msg.SubredditId)
+	if err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error(), Code: pb.ErrorCode_NOT_FOUND})
+		return
+	}
+
+	response := &pb.FlairTemplatesResponse{
+		PostFlairs: make([]*pb.FlairMessage, 0, len(subreddit.PostFlairs)),
+		UserFlairs: make([]*pb.FlairMessage, 0, len(subreddit.UserFlairs)),
+	}
+	for _, flair := range subreddit.PostFlairs {
+		response.PostFlairs = append(response.PostFlairs, flairMessage(flair))
+	}
+	for _, flair := range subreddit.UserFlairs {
+		response.UserFlairs = append(response.UserFlairs, flairMessage(flair))
+	}
+	context.Respond(response)
+}
+
+// chooseFlair looks up a template userID may assign, returning a copy so
+// later template edits don't rewrite flair already on posts.
+func chooseFlair(subreddit *models.Subreddit, templates []*models.Flair, flairID, userID string) (*models.Flair, error) {
+	template := models.FindFlair(templates, flairID)
+	if template == nil {
+		return nil, errors.New("flair not found")
+	}
+	if template.ModOnly && !isModerator(subreddit, userID) {
+		return nil, errors.New("only moderators can assign this flair")
+	}
+	flair := *template
+	return &flair, nil
+}
+
+func (e *EngineActor) handleAssignPostFlair(context actor.Context, msg *pb.AssignPostFlairMessage) {
+	start := time.Now()
+
+	if !e.authenticate(context, msg.UserId, msg.SessionId) {
+		return
+	}
+	post, err := e.store.GetPost(msg.PostId)
+	if err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error(), Code: pb.ErrorCode_NOT_FOUND})
+		return
+	}
+	subreddit, err := e.store.GetSubreddit(post.SubredditID)
+	if err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error(), Code: pb.ErrorCode_NOT_FOUND})
+		return
+	}
+
+	if msg.UserId != post.AuthorID && !isModerator(subreddit, msg.UserId) {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: "only the author or a moderator can change post flair", Code: pb.ErrorCode_FORBIDDEN})
+		return
+	}
+
+	var flair *models.Flair
+	if msg.FlairId != "" {
+		flair, err = chooseFlair(subreddit, subreddit.PostFlairs, msg.FlairId, msg.UserId)
+		if err != nil {
+			e.metrics.RecordError()
+			context.Respond(&pb.ErrorResponse{Error: err.Error(), Code: pb.ErrorCode_FORBIDDEN})
+			return
+		}
+	}
+
+	err = e.store.SetPostFlair(post.ID, flair)
+	if err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if msg.UserId != post.AuthorID {
+		e.logModAction(subreddit.ID, msg.UserId, models.ModFlair, post.ID, flairText(flair))
+	}
+
+	e.metrics.RecordRequest(time.Since(start).Seconds())
+	context.Respond(&pb.SuccessResponse{Message: "Post flair updated successfully"})
+}
+
+func (e *EngineActor) handleAssignUserFlair(context actor.Context, msg *pb.AssignUserFlairMessage) {
+	start := time.Now()
+
+	if !e.authenticate(context, msg.UserId, msg.SessionId) {
+		return
+	}
+	subreddit, err := e.store.GetSubreddit(msg.SubredditId)
+	if err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error(), Code: pb.ErrorCode_NOT_FOUND})
+		return
+	}
+
+	targetID := msg.TargetUserId
+	if targetID == "" {
+		targetID = msg.UserId
+	}
+	if targetID != msg.UserId && !isModerator(subreddit, msg.UserId) {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: "only moderators can set other users' flair", Code: pb.ErrorCode_FORBIDDEN})
+		return
+	}
+
+	var flair *models.Flair
+	if msg.FlairId != "" {
+		flair, err = chooseFlair(subreddit, subreddit.UserFlairs, msg.FlairId, msg.UserId)
+		if err != nil {
+			e.metrics.RecordError()
+			context.Respond(&pb.ErrorResponse{Error: err.Error(), Code: pb.ErrorCode_FORBIDDEN})
+			return
+		}
+	}
+
+	err = e.store.SetUserFlair(subreddit.ID, targetID, msg.FlairId)
+	if err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if targetID != msg.UserId {
+		e.logModAction(subreddit.ID, msg.UserId, models.ModFlair, targetID, flairText(flair))
+	}
+
+	e.metrics.RecordRequest(time.Since(start).Seconds())
+	context.Respond(&pb.SuccessResponse{Message: "User flair updated successfully"})
+}
+
+// authorFlair resolves the post author's current user flair in the post's subreddit
+func (e *EngineActor) authorFlair(post *models.Post) *pb.FlairMessage {
+	subreddit, err := e.store.GetSubreddit(post.SubredditID)
+	if err != nil {
+		return nil
+	}
+	flairID, exists := subreddit.UserFlair[post.AuthorID]
+	if !exists {
+		return nil
+	}
+	if flair := models.FindFlair(subreddit.UserFlairs, flairID); flair != nil {
+		return flairMessage(flair)
+	}
+	return nil
+}
+
+// hasFlair reports whether the post matches a flair filter; an empty filter matches everything
+func hasFlair(post *models.Post, flairID string) bool {
+	return flairID == "" || (post.Flair != nil && post.Flair.ID == flairID)
+}
+
+func (e *EngineActor) handleGetSubredditPosts(context actor.Context, msg *pb.GetSubredditPostsMessage) {
+	start := time.Now()
+
+	if !e.checkAccess(context, msg.SubredditId, msg.UserId, false) {
+		return
+	}
+
+	posts, err := e.store.GetSubredditPosts(msg.SubredditId)
+	if err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error(), Code: pb.ErrorCode_NOT_FOUND})
+		return
+	}
+
+	// Stickies first, then newest first
+	listing := make([]*models.Post, 0, len(posts))
+	for _, post := range posts {
+		if post.Removed || post.Filtered || !hasFlair(post, msg.FlairId) {
+			continue
+		}
+		listing = append(listing, post)
+	}
+	sort.Slice(listing, func(i, j int) bool {
+		if listing[i].Created != listing[j].Created {
+			return listing[i].Created > listing[j].Created
+		}
+		return listing[i].ID < listing[j].ID
+	})
+	listing = e.pinStickies(msg.SubredditId, listing)
+
+	page, next := paginate(listing, func(p *models.Post) string { return p.ID }, msg.After, msg.Limit)
+
+	saved := e.savedSet(msg.UserId)
+	response := &pb.FeedResponse{
+		Posts: make([]*pb.PostMessage, 0, len(page)),
+		Next:  next,
+	}
+	for _, post := range page {
+		protoPost := postMessage(post)
+		protoPost.Saved = saved[post.ID]
+		protoPost.AuthorFlair = e.authorFlair(post)
+		response.Posts = append(response.Posts, protoPost)
+	}
+
+	e.metrics.RecordRequest(time.Since(start).Seconds())
+	context.Respond(response)
+}
+
+func flairText(flair *models.Flair) string {
+	if flair == nil {
+		return "cleared"
+	}
+	return flair.Text
+}
+
+func flairMessage(flair *models.Flair) *pb.FlairMessage {
+	return &pb.FlairMessage{
+		Id:      flair.ID,
+		Text:    flair.Text,
+		Color:   flair.Color,
+		ModOnly: flair.ModOnly,
+	}
+}
@@ -0,0 +1,211 @@
+package actor
+
+import (
+	"github.com/asynkron/protoactor-go/actor"
+	pb "reddit-clone/api/proto/generated"
+	"reddit-clone/internal/models"
+	"reddit-clone/internal/recommend"
+	"sort"
+	"time"
+)
+
+// forYou holds the signals and the latest trained model behind the "for you" feed
+type forYou struct {
+	interactions *recommend.Interactions
+	model        *recommend.PostModel
+	training     bool
+	trained      time.Time // when the last training run started
+}
+
+func newForYou() *forYou {
+	return &forYou{interactions: recommend.NewInteractions()}
+}
+
+// postModelTrained is sent to the engine when a background training run finishes
+type postModelTrained struct {
+	model    *recommend.PostModel
+	eligible map[string]bool // posts recent enough to recommend when training started
+	duration time.Duration
+}
+
+// recordSignal adds a for-you signal from userID on the post a target belongs to
+func (e *EngineActor) recordSignal(userID, targetID string, weight float64) {
+	post, err := e.store.GetPost(targetID)
+	if err != nil {
+		comment, err := e.store.GetComment(targetID)
+		if err != nil {
+			return
+		}
+		if post, err = e.store.GetPost(comment.PostID); err != nil {
+			return
+		}
+	}
+	e.forYou.interactions.Add(userID, post.ID, post.Created, weight)
+}
+
+func (e *EngineActor) handleItemSaved(msg *itemSaved) {
+	weight := e.config.ForYou.SaveWeight
+	if !msg.Saved {
+		weight = -weight
+	}
+	e.recordSignal(msg.UserID, msg.TargetID, weight)
+}
+
+// rebuildForYou replays recent signals after a restart; the first tick trains on them
+func (e *EngineActor) rebuildForYou() error {
+	cfg := e.config.ForYou
+	since := time.Now().Add(-cfg.MaxAge).Unix()
+
+	posts, err := e.store.GetPostsSince(since)
+	if err != nil {
+		return err
+	}
+	for _, post := range posts {
+		e.forYou.interactions.Add(post.AuthorID, post.ID, post.Created, 0)
+		votes, err := e.store.GetVotes(post.ID)
+		if err != nil {
+			return err
+		}
+		for userID, isUpvote := range votes {
+			e.recordSignal(userID, post.ID, voteWeight(cfg.UpvoteWeight, isUpvote))
+		}
+	}
+
+	comments, err := e.store.GetCommentsSince(since)
+	if err != nil {
+		return err
+	}
+	for _, comment := range comments {
+		e.recordSignal(comment.AuthorID, comment.PostID, cfg.CommentWeight)
+	}
+
+	saved, err := e.store.GetSavedItemsSince(since)
+	if err != nil {
+		return err
+	}
+	for _, item := range saved {
+		e.recordSignal(item.UserID, item.TargetID, cfg.SaveWeight)
+	}
+	return nil
+}
+
+func voteWeight(weight float64, isUpvote bool) float64 {
+	if isUpvote {
+		return weight
+	}
+	return -weight
+}
+
+// retrainForYou starts a training run in the background if one is due. The
+// goroutine works on a snapshot and reports back by message, so the engine's
+// own state is never touched off the actor.
+func (e *EngineActor) retrainForYou(context actor.Context, now time.Time) {
+	cfg := e.config.ForYou
+	if e.forYou.training || now.Sub(e.forYou.trained) < cfg.RetrainInterval {
+		return
+	}
+
+	cutoff := now.Add(-cfg.MaxAge).Unix()
+	e.forYou.interactions.Prune(cutoff)
+	posts, err := e.store.GetPostsSince(cutoff)
+	if err != nil {
+		e.metrics.RecordError()
+		return
+	}
+	eligible := make(map[string]bool, len(posts))
+	for _, post := range posts {
+		if !post.Removed && !post.Filtered {
+			eligible[post.ID] = true
+		}
+	}
+
+	e.forYou.training = true
+	e.forYou.trained = now
+	signals := e.forYou.interactions.Snapshot(cfg.MaxItemsPerUser)
+	root, self := context.ActorSystem().Root, context.Self()
+	go func() {
+		start := time.Now()
+		model := recommend.TrainPosts(signals, cfg.Neighbours)
+		root.Send(self, &postModelTrained{model: model, eligible: eligible, duration: time.Since(start)})
+	}()
+}
+
+func (e *EngineActor) handlePostModelTrained(msg *postModelTrained) {
+	e.forYou.training = false
+	e.forYou.model = msg.model
+
+	covered := 0
+	for postID := range msg.eligible {
+		if msg.model.Covers(postID) {
+			covered++
+		}
+	}
+	postCoverage := 0.0
+	if len(msg.eligible) > 0 {
+		postCoverage = float64(covered) / float64(len(msg.eligible))
+	}
+	e.metrics.RecordForYouTrained(msg.duration.Seconds(), postCoverage, msg.model.UserCoverage)
+}
+
+func (e *EngineActor) handleGetForYouFeed(context actor.Context, msg *pb.GetFeedMessage, within map[string]bool, start time.Time) {
+	feed, err := e.forYouFeed(msg, within, start)
+	if err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	e.metrics.RecordRequest(time.Since(start).Seconds())
+	e.respondFeed(context, msg.UserId, feed, msg.After, msg.Limit)
+}
+
+// forYouFeed ranks unseen recent posts by similarity to what the user has
+// engaged with, falling back to the hottest unseen posts when the model has
+// nothing for them. A non-nil within limits it to those subreddits.
+func (e *EngineActor) forYouFeed(msg *pb.GetFeedMessage, within map[string]bool, now time.Time) ([]*models.Post, error) {
+	cutoff := now.Add(-e.config.ForYou.MaxAge).Unix()
+	seen := e.forYou.interactions.Of(msg.UserId)
+	visible := func(post *models.Post) bool {
+		return post.Created >= cutoff && !post.Removed && !post.Filtered && hasFlair(post, msg.FlairId) &&
+			(within == nil || within[post.SubredditID]) && e.readable(post.SubredditID, msg.UserId)
+	}
+
+	var feed []*models.Post
+	if e.forYou.model != nil {
+		allow := func(postID string) bool {
+			post, err := e.store.GetPost(postID)
+			return err == nil && visible(post)
+		}
+		for _, recommendation := range e.forYou.model.Recommend(seen, -1, allow) {
+			if post, err := e.store.GetPost(recommendation.PostID); err == nil {
+				feed = append(feed, post)
+			}
+		}
+	}
+	if len(feed) > 0 {
+		e.metrics.RecordForYouServed("model")
+		return feed, nil
+	}
+
+	posts, err := e.store.GetPostsSince(cutoff)
+	if err != nil {
+		return nil, err
+	}
+	for _, post := range posts {
+		if _, exists := seen[post.ID]; !exists && visible(post) {
+			feed = append(feed, post)
+		}
+	}
+	sortHot(feed, now)
+	e.metrics.RecordForYouServed("fallback")
+	return feed, nil
+}
+
+// sortHot orders posts by karma per hour of age, hottest first
+func sortHot(posts []*models.Post, now time.Time) {
+	sort.SliceStable(posts, func(i, j int) bool {
+		scoreI := float64(posts[i].Karma) / now.Sub(time.Unix(posts[i].Created, 0)).Hours()
+		scoreJ := float64(posts[j].Karma) / now.Sub(time.Unix(posts[j].Created, 0)).Hours()
+		return scoreI > scoreJ
+	})
+}
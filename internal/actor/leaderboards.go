@@ -0,0 +1,171 @@
+package actor
+
+import (
+	"github.com/asynkron/protoactor-go/actor"
+	pb "reddit-clone/api/proto/generated"
+	"reddit-clone/internal/leaderboard"
+	"reddit-clone/internal/models"
+	"strings"
+	"time"
+)
+
+// leaderboards are updated as posts, comments, votes and joins are handled
+type leaderboards struct {
+	karma          *leaderboard.Board            // user -> karma earned
+	subredditKarma map[string]*leaderboard.Board // subreddit -> user -> karma earned there
+	posters        *leaderboard.Board            // user -> posts
+	commenters     *leaderboard.Board            // user -> comments
+	growth         *leaderboard.Board            // subreddit -> members gained
+}
+
+func newLeaderboards() *leaderboards {
+	return &leaderboards{
+		karma:          leaderboard.NewBoard(),
+		subredditKarma: make(map[string]*leaderboard.Board),
+		posters:        leaderboard.NewBoard(),
+		commenters:     leaderboard.NewBoard(),
+		growth:         leaderboard.NewBoard(),
+	}
+}
+
+func (l *leaderboards) addKarma(subredditID, userID string, delta int64, at time.Time) {
+	l.karma.Add(userID, delta, at)
+	if subredditID == "" {
+		return
+	}
+	board, exists := l.subredditKarma[subredditID]
+	if !exists {
+		board = leaderboard.NewBoard()
+		l.subredditKarma[subredditID] = board
+	}
+	board.Add(userID, delta, at)
+}
+
+// rebuildLeaderboards replays stored content after a restart. Karma has no
+// timestamps of its own, so it is credited at the time of the content it was earned on.
+func (e *EngineActor) rebuildLeaderboards(subreddits []*models.Subreddit) error {
+	for _, subreddit := range subreddits {
+		e.leaderboards.growth.Add(subreddit.ID, int64(len(subreddit.Members)), time.Unix(subreddit.Created, 0))
+	}
+
+	posts, err := e.store.GetPostsSince(0)
+	if err != nil {
+		return err
+	}
+	postSubreddits := make(map[string]string, len(posts))
+	for _, post := range posts {
+		created := time.Unix(post.Created, 0)
+		e.leaderboards.posters.Add(post.AuthorID, 1, created)
+		e.leaderboards.addKarma(post.SubredditID, post.AuthorID, int64(post.Karma), created)
+		postSubreddits[post.ID] = post.SubredditID
+	}
+
+	comments, err := e.store.GetCommentsSince(0)
+	if err != nil {
+		return err
+	}
+	for _, comment := range comments {
+		created := time.Unix(comment.Created, 0)
+		e.leaderboards.commenters.Add(comment.AuthorID, 1, created)
+		e.leaderboards.addKarma(postSubreddits[comment.PostID], comment.AuthorID, int64(comment.Karma), created)
+	}
+	return nil
+}
+
+// board returns the leaderboard a request refers to, or nil if there is none
+func (e *EngineActor) board(kind pb.LeaderboardType, subredditID string) *leaderboard.Board {
+	switch kind {
+	case pb.LeaderboardType_LEADERBOARD_KARMA:
+		if subredditID != "" {
+			return e.leaderboards.subredditKarma[subredditID]
+		}
+		return e.leaderboards.karma
+	case pb.LeaderboardType_LEADERBOARD_POSTERS:
+		return e.leaderboards.posters
+	case pb.LeaderboardType_LEADERBOARD_COMMENTERS:
+		return e.leaderboards.commenters
+	case pb.LeaderboardType_LEADERBOARD_SUBREDDIT_GROWTH:
+		return e.leaderboards.growth
+	}
+	return nil
+}
+
+func (e *EngineActor) handleGetLeaderboard(context actor.Context, msg *pb.GetLeaderboardMessage) {
+	start := time.Now()
+
+	if !e.authenticateViewer(context, msg.UserId, msg.SessionId) {
+		return
+	}
+	if msg.SubredditId != "" {
+		if msg.Type != pb.LeaderboardType_LEADERBOARD_KARMA {
+			e.metrics.RecordError()
+			context.Respond(&pb.ErrorResponse{Error: "only the karma leaderboard can be limited to a subreddit"})
+			return
+		}
+		if !e.checkAccess(context, msg.SubredditId, msg.UserId, false) {
+			return
+		}
+	}
+
+	limit := int(msg.Limit)
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+
+	response := &pb.LeaderboardResponse{}
+	if board := e.board(msg.Type, msg.SubredditId); board != nil {
+		top := board.Top(leaderboard.Window(msg.Window), limit, start)
+		response.Entries = make([]*pb.LeaderboardEntry, 0, len(top))
+		for i, entry := range top {
+			response.Entries = append(response.Entries, &pb.LeaderboardEntry{
+				Rank:  int32(i + 1),
+				Id:    entry.ID,
+				Name:  e.leaderboardName(msg.Type, entry.ID),
+				Score: entry.Score,
+			})
+		}
+	}
+
+	e.metrics.RecordRequest(time.Since(start).Seconds())
+	context.Respond(response)
+}
+
+func (e *EngineActor) leaderboardName(kind pb.LeaderboardType, id string) string {
+	if kind == pb.LeaderboardType_LEADERBOARD_SUBREDDIT_GROWTH {
+		if subreddit, err := e.store.GetSubreddit(id); err == nil {
+			return subreddit.Name
+		}
+		return ""
+	}
+	if user, err := e.store.GetUser(id); err == nil {
+		return user.Username
+	}
+	return ""
+}
+
+// publishLeaderboards exports the top of each site-wide leaderboard as gauges
+func (e *EngineActor) publishLeaderboards(now time.Time) {
+	e.metrics.ResetLeaderboards()
+	for _, kind := range []pb.LeaderboardType{
+		pb.LeaderboardType_LEADERBOARD_KARMA,
+		pb.LeaderboardType_LEADERBOARD_POSTERS,
+		pb.LeaderboardType_LEADERBOARD_COMMENTERS,
+		pb.LeaderboardType_LEADERBOARD_SUBREDDIT_GROWTH,
+	} {
+		boardName := strings.ToLower(strings.TrimPrefix(kind.String(), "LEADERBOARD_"))
+		for _, window := range []pb.LeaderboardWindow{
+			pb.LeaderboardWindow_WINDOW_ALL_TIME,
+			pb.LeaderboardWindow_WINDOW_DAY,
+			pb.LeaderboardWindow_WINDOW_WEEK,
+		} {
+			windowName := strings.ToLower(strings.TrimPrefix(window.String(), "WINDOW_"))
+			top := e.board(kind, "").Top(leaderboard.Window(window), e.config.Leaderboards.MetricsSize, now)
+			for i, entry := range top {
+				e.metrics.SetLeaderboardScore(boardName, windowName, i+1, entry.ID, float64(entry.Score))
+			}
+		}
+	}
+}
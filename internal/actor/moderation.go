@@ -0,0 +1,288 @@
+package actor
+
+import (
+	"fmt"
+	"github.com/asynkron/protoactor-go/actor"
+	pb "reddit-clone/api/proto/generated"
+	"reddit-clone/internal/models"
+	"time"
+)
+
+func isModerator(subreddit *models.Subreddit, userID string) bool {
+	for _, moderatorID := range subreddit.Moderators {
+		if moderatorID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// requireModerator authenticates userID, loads the subreddit and checks
+// userID moderates it, responding with an error and returning nil if not.
+func (e *EngineActor) requireModerator(context actor.Context, subredditID, userID, sessionID string) *models.Subreddit {
+	if !e.authenticate(context, userID, sessionID) {
+		return nil
+	}
+	subreddit, err := e.store.GetSubreddit(subredditID)
+	if err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error(), Code: pb.ErrorCode_NOT_FOUND})
+		return nil
+	}
+	if !isModerator(subreddit, userID) {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: "moderator permissions required", Code: pb.ErrorCode_FORBIDDEN})
+		return nil
+	}
+	return subreddit
+}
+
+// checkBanned responds with BANNED and returns false if userID is banned from subredditID
+func (e *EngineActor) checkBanned(context actor.Context, subredditID, userID string) bool {
+	if subredditID == "" {
+		return true
+	}
+	subreddit, err := e.store.GetSubreddit(subredditID)
+	if err != nil {
+		return true
+	}
+	ban, banned := subreddit.Bans[userID]
+	if !banned || !ban.Active(time.Now().Unix()) {
+		return true
+	}
+
+	e.metrics.RecordError()
+	context.Respond(&pb.ErrorResponse{Error: "user is banned from this subreddit", Code: pb.ErrorCode_BANNED})
+	return false
+}
+
+// targetSubreddit resolves the subreddit a post or comment belongs to
+func (e *EngineActor) targetSubreddit(targetID string) string {
+	if post, err := e.store.GetPost(targetID); err == nil {
+		return post.SubredditID
+	}
+	if comment, err := e.store.GetComment(targetID); err == nil {
+		if post, err := e.store.GetPost(comment.PostID); err == nil {
+			return post.SubredditID
+		}
+	}
+	return ""
+}
+
+// contentTarget is a post or comment referenced by a moderation or report request
+type contentTarget struct {
+	Type        models.TargetType
+	ID          string
+	SubredditID string
+	AuthorID    string
+	Title       string
+	Content     string
+	Approved    bool
+	Filtered    bool
+	Created     int64
+}
+
+// findTarget looks up a post or comment by ID
+func (e *EngineActor) findTarget(targetID string, targetType models.TargetType) (*contentTarget, error) {
+	if targetType == models.TargetComment {
+		comment, err := e.store.GetComment(targetID)
+		if err != nil {
+			return nil, err
+		}
+		return &contentTarget{
+			Type:        models.TargetComment,
+			ID:          comment.ID,
+			SubredditID: e.targetSubreddit(comment.ID),
+			AuthorID:    comment.AuthorID,
+			Content:     comment.Content,
+			Approved:    comment.Approved,
+			Filtered:    comment.Filtered,
+			Created:     comment.Created,
+		}, nil
+	}
+
+	post, err := e.store.GetPost(targetID)
+	if err != nil {
+		return nil, err
+	}
+	return &contentTarget{
+		Type:        models.TargetPost,
+		ID:          post.ID,
+		SubredditID: post.SubredditID,
+		AuthorID:    post.AuthorID,
+		Title:       post.Title,
+		Content:     post.Content,
+		Approved:    post.Approved,
+		Filtered:    post.Filtered,
+		Created:     post.Created,
+	}, nil
+}
+
+// setRemoved removes or approves a target, which also takes it out of the mod queue
+func (e *EngineActor) setRemoved(target *contentTarget, removed bool) error {
+	var err error
+	if target.Type == models.TargetComment {
+		err = e.store.SetCommentRemoved(target.ID, removed)
+	} else {
+		err = e.store.SetPostRemoved(target.ID, removed)
+	}
+	if err != nil {
+		return err
+	}
+	// Not every target has been reported
+	_ = e.store.ClearReports(target.ID)
+	return nil
+}
+
+func (e *EngineActor) handleModerateContent(context actor.Context, msg *pb.ModerateContentMessage) {
+	start := time.Now()
+
+	target, err := e.findTarget(msg.TargetId, models.TargetType(msg.TargetType))
+	if err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error(), Code: pb.ErrorCode_NOT_FOUND})
+		return
+	}
+
+	if e.requireModerator(context, target.SubredditID, msg.ModeratorId, msg.SessionId) == nil {
+		return
+	}
+
+	err = e.setRemoved(target, !msg.Approve)
+	if err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	action, result := models.ModRemove, "Content removed successfully"
+	if msg.Approve {
+		action, result = models.ModApprove, "Content approved successfully"
+	}
+	e.logModAction(target.SubredditID, msg.ModeratorId, action, msg.TargetId, msg.Reason)
+
+	e.metrics.RecordRequest(time.Since(start).Seconds())
+	context.Respond(&pb.SuccessResponse{Message: result})
+}
+
+func (e *EngineActor) handleBanUser(context actor.Context, msg *pb.BanUserMessage) {
+	start := time.Now()
+
+	subreddit := e.requireModerator(context, msg.SubredditId, msg.ModeratorId, msg.SessionId)
+	if subreddit == nil {
+		return
+	}
+	if msg.UserId == msg.ModeratorId {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: "moderators cannot ban themselves", Code: pb.ErrorCode_FORBIDDEN})
+		return
+	}
+	if msg.UserId == subreddit.CreatorID {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: "the subreddit's creator cannot be banned", Code: pb.ErrorCode_FORBIDDEN})
+		return
+	}
+	// As with removing moderators, only the creator may ban one
+	bannedModerator := isModerator(subreddit, msg.UserId)
+	if bannedModerator && msg.ModeratorId != subreddit.CreatorID {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: "only the creator can ban moderators", Code: pb.ErrorCode_FORBIDDEN})
+		return
+	}
+
+	now := time.Now()
+	ban := &models.Ban{
+		UserID:      msg.UserId,
+		ModeratorID: msg.ModeratorId,
+		Reason:      msg.Reason,
+		Created:     now.Unix(),
+	}
+	if msg.DurationSeconds > 0 {
+		ban.Expires = now.Unix() + msg.DurationSeconds
+	}
+
+	err := e.store.BanUser(msg.SubredditId, ban)
+	if err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	reason := msg.Reason
+	if msg.DurationSeconds > 0 {
+		reason = fmt.Sprintf("%s (%s)", reason, time.Duration(msg.DurationSeconds)*time.Second)
+	}
+	e.logModAction(msg.SubredditId, msg.ModeratorId, models.ModBan, msg.UserId, reason)
+	if bannedModerator {
+		if err := e.store.RemoveModerator(msg.SubredditId, msg.UserId); err != nil {
+			e.metrics.RecordError()
+		} else {
+			e.logModAction(msg.SubredditId, msg.ModeratorId, models.ModRemoveModerator, msg.UserId, "banned")
+		}
+	}
+	e.pruneSubscriptions(context, msg.SubredditId)
+
+	e.metrics.RecordRequest(time.Since(start).Seconds())
+	context.Respond(&pb.SuccessResponse{Message: "User banned successfully"})
+}
+
+func (e *EngineActor) handleUnbanUser(context actor.Context, msg *pb.UnbanUserMessage) {
+	start := time.Now()
+
+	if e.requireModerator(context, msg.SubredditId, msg.ModeratorId, msg.SessionId) == nil {
+		return
+	}
+
+	err := e.store.UnbanUser(msg.SubredditId, msg.UserId)
+	if err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error(), Code: pb.ErrorCode_NOT_FOUND})
+		return
+	}
+
+	e.logModAction(msg.SubredditId, msg.ModeratorId, models.ModUnban, msg.UserId, "")
+
+	e.metrics.RecordRequest(time.Since(start).Seconds())
+	context.Respond(&pb.SuccessResponse{Message: "User unbanned successfully"})
+}
+
+func (e *EngineActor) handleModeratorMessage(context actor.Context, msg *pb.ModeratorMessage) {
+	start := time.Now()
+
+	subreddit := e.requireModerator(context, msg.SubredditId, msg.ModeratorId, msg.SessionId)
+	if subreddit == nil {
+		return
+	}
+
+	var err error
+	if msg.Remove {
+		// Only the creator may remove other moderators; anyone may step down
+		if msg.UserId != msg.ModeratorId && msg.ModeratorId != subreddit.CreatorID {
+			e.metrics.RecordError()
+			context.Respond(&pb.ErrorResponse{Error: "only the creator can remove moderators", Code: pb.ErrorCode_FORBIDDEN})
+			return
+		}
+		err = e.store.RemoveModerator(msg.SubredditId, msg.UserId)
+	} else {
+		if _, err := e.store.GetUser(msg.UserId); err != nil {
+			e.metrics.RecordError()
+			context.Respond(&pb.ErrorResponse{Error: err.Error(), Code: pb.ErrorCode_NOT_FOUND})
+			return
+		}
+		err = e.store.AddModerator(msg.SubredditId, msg.UserId)
+	}
+	if err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	action := models.ModAddModerator
+	if msg.Remove {
+		action = models.ModRemoveModerator
+	}
+	e.logModAction(msg.SubredditId, msg.ModeratorId, action, msg.UserId, "")
+
+	e.metrics.RecordRequest(time.Since(start).Seconds())
+	context.Respond(&pb.SuccessResponse{Message: "Moderators updated successfully"})
+}
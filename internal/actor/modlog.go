@@ -0,0 +1,83 @@
+package actor
+
+import (
+	"github.com/asynkron/protoactor-go/actor"
+	pb "reddit-clone/api/proto/generated"
+	"reddit-clone/internal/models"
+	"reddit-clone/pkg/utils"
+	"time"
+)
+
+// logModAction appends an entry to the subreddit's moderation log
+func (e *EngineActor) logModAction(subredditID, moderatorID string, action models.ModAction, targetID, reason string) {
+	entry := &models.ModLogEntry{
+		ID:          utils.GenerateID(),
+		SubredditID: subredditID,
+		ModeratorID: moderatorID,
+		Action:      action,
+		TargetID:    targetID,
+		Reason:      reason,
+		Created:     time.Now().Unix(),
+	}
+	if err := e.store.AppendModLog(entry); err != nil {
+		e.metrics.RecordError()
+	}
+}
+
+func (e *EngineActor) handleGetModLog(context actor.Context, msg *pb.GetModLogMessage) {
+	start := time.Now()
+
+	if e.requireModerator(context, msg.SubredditId, msg.RequesterId, msg.SessionId) == nil {
+		return
+	}
+
+	entries, err := e.store.GetModLog(msg.SubredditId)
+	if err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	// Newest first, applying filters as we go
+	matched := make([]*models.ModLogEntry, 0, len(entries))
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		if msg.Action != "" && string(entry.Action) != msg.Action {
+			continue
+		}
+		if msg.ModeratorId != "" && entry.ModeratorID != msg.ModeratorId {
+			continue
+		}
+		if msg.TargetId != "" && entry.TargetID != msg.TargetId {
+			continue
+		}
+		if msg.Since > 0 && entry.Created < msg.Since {
+			continue
+		}
+		if msg.Until > 0 && entry.Created > msg.Until {
+			continue
+		}
+		matched = append(matched, entry)
+	}
+
+	page, next := paginate(matched, func(entry *models.ModLogEntry) string { return entry.ID }, msg.After, msg.Limit)
+
+	response := &pb.ModLogResponse{
+		Entries: make([]*pb.ModLogEntryMessage, 0, len(page)),
+		Next:    next,
+	}
+	for _, entry := range page {
+		response.Entries = append(response.Entries, &pb.ModLogEntryMessage{
+			Id:          entry.ID,
+			SubredditId: entry.SubredditID,
+			ModeratorId: entry.ModeratorID,
+			Action:      string(entry.Action),
+			TargetId:    entry.TargetID,
+			Reason:      entry.Reason,
+			CreatedAt:   entry.Created,
+		})
+	}
+
+	e.metrics.RecordRequest(time.Since(start).Seconds())
+	context.Respond(response)
+}
@@ -0,0 +1,275 @@
+package actor
+
+import (
+	"fmt"
+	"github.com/asynkron/protoactor-go/actor"
+	pb "reddit-clone/api/proto/generated"
+	"reddit-clone/internal/models"
+	"strings"
+	"time"
+)
+
+func (e *EngineActor) handleCreateMultireddit(context actor.Context, msg *pb.CreateMultiredditMessage) {
+	start := time.Now()
+
+	if !e.authenticate(context, msg.OwnerId, msg.SessionId) {
+		return
+	}
+	if _, err := e.store.GetUser(msg.OwnerId); err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error(), Code: pb.ErrorCode_NOT_FOUND})
+		return
+	}
+
+	multireddit := &models.Multireddit{
+		ID:          msg.Id,
+		OwnerID:     msg.OwnerId,
+		Name:        msg.Name,
+		Description: msg.Description,
+		Public:      msg.Public,
+		Created:     start.Unix(),
+		Updated:     start.Unix(),
+	}
+	if !e.checkMultireddit(context, multireddit, msg.SubredditIds) {
+		return
+	}
+	if err := e.store.CreateMultireddit(multireddit); err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error(), Code: pb.ErrorCode_DUPLICATE})
+		return
+	}
+
+	e.metrics.RecordRequest(time.Since(start).Seconds())
+	context.Respond(&pb.SuccessResponse{Message: "Multireddit created successfully"})
+}
+
+func (e *EngineActor) handleUpdateMultireddit(context actor.Context, msg *pb.UpdateMultiredditMessage) {
+	start := time.Now()
+
+	if !e.authenticate(context, msg.UserId, msg.SessionId) {
+		return
+	}
+	current := e.ownedMultireddit(context, msg.Id, msg.UserId)
+	if current == nil {
+		return
+	}
+
+	next := *current
+	next.Name = msg.Name
+	next.Description = msg.Description
+	next.Public = msg.Public
+	next.Updated = start.Unix()
+	if !e.checkMultireddit(context, &next, msg.SubredditIds) {
+		return
+	}
+	if err := e.store.UpdateMultireddit(&next); err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error(), Code: pb.ErrorCode_NOT_FOUND})
+		return
+	}
+
+	e.metrics.RecordRequest(time.Since(start).Seconds())
+	context.Respond(&pb.SuccessResponse{Message: "Multireddit updated successfully"})
+}
+
+func (e *EngineActor) handleDeleteMultireddit(context actor.Context, msg *pb.DeleteMultiredditMessage) {
+	start := time.Now()
+
+	if !e.authenticate(context, msg.UserId, msg.SessionId) {
+		return
+	}
+	if e.ownedMultireddit(context, msg.Id, msg.UserId) == nil {
+		return
+	}
+	if err := e.store.DeleteMultireddit(msg.Id); err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error(), Code: pb.ErrorCode_NOT_FOUND})
+		return
+	}
+
+	e.metrics.RecordRequest(time.Since(start).Seconds())
+	context.Respond(&pb.SuccessResponse{Message: "Multireddit deleted successfully"})
+}
+
+func (e *EngineActor) handleGetMultireddit(context actor.Context, msg *pb.GetMultiredditMessage) {
+	start := time.Now()
+
+	multireddit := e.viewableMultireddit(context, msg.Id, msg.UserId)
+	if multireddit == nil {
+		return
+	}
+
+	e.metrics.RecordRequest(time.Since(start).Seconds())
+	context.Respond(e.multiredditMessage(multireddit, msg.UserId))
+}
+
+func (e *EngineActor) handleGetMultireddits(context actor.Context, msg *pb.GetMultiredditsMessage) {
+	start := time.Now()
+
+	multireddits, err := e.store.GetUserMultireddits(msg.OwnerId)
+	if err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	response := &pb.MultiredditsResponse{Multireddits: make([]*pb.MultiredditMessage, 0, len(multireddits))}
+	for _, multireddit := range multireddits {
+		if multireddit.Public || multireddit.OwnerID == msg.UserId {
+			response.Multireddits = append(response.Multireddits, e.multiredditMessage(multireddit, msg.UserId))
+		}
+	}
+
+	e.metrics.RecordRequest(time.Since(start).Seconds())
+	context.Respond(response)
+}
+
+func (e *EngineActor) handleCopyMultireddit(context actor.Context, msg *pb.CopyMultiredditMessage) {
+	start := time.Now()
+
+	if !e.authenticate(context, msg.UserId, msg.SessionId) {
+		return
+	}
+	original := e.viewableMultireddit(context, msg.Id, msg.UserId)
+	if original == nil {
+		return
+	}
+
+	name := msg.Name
+	if strings.TrimSpace(name) == "" {
+		name = original.Name
+	}
+	multireddit := &models.Multireddit{
+		ID:          msg.NewId,
+		OwnerID:     msg.UserId,
+		Name:        name,
+		Description: original.Description,
+		Created:     start.Unix(),
+		Updated:     start.Unix(),
+	}
+	if !e.checkMultireddit(context, multireddit, original.SubredditIDs) {
+		return
+	}
+	if err := e.store.CreateMultireddit(multireddit); err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error(), Code: pb.ErrorCode_DUPLICATE})
+		return
+	}
+
+	e.metrics.RecordRequest(time.Since(start).Seconds())
+	context.Respond(&pb.SuccessResponse{Message: "Multireddit copied successfully"})
+}
+
+// checkMultireddit normalises the name and subreddit list into multireddit
+// and checks them against the owner's other multireddits, responding with an
+// error and returning false if they are invalid
+func (e *EngineActor) checkMultireddit(context actor.Context, multireddit *models.Multireddit, subredditIDs []string) bool {
+	cfg := e.config.Multireddits
+
+	multireddit.Name = strings.TrimSpace(multireddit.Name)
+	if multireddit.Name == "" || len(multireddit.Name) > cfg.MaxNameLength {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: fmt.Sprintf("multireddit name must be 1 to %d characters", cfg.MaxNameLength)})
+		return false
+	}
+
+	multireddit.SubredditIDs = make([]string, 0, len(subredditIDs))
+	included := make(map[string]bool, len(subredditIDs))
+	for _, subredditID := range subredditIDs {
+		if included[subredditID] {
+			continue
+		}
+		if _, err := e.store.GetSubreddit(subredditID); err != nil {
+			e.metrics.RecordError()
+			context.Respond(&pb.ErrorResponse{Error: fmt.Sprintf("subreddit %s not found", subredditID), Code: pb.ErrorCode_NOT_FOUND})
+			return false
+		}
+		included[subredditID] = true
+		multireddit.SubredditIDs = append(multireddit.SubredditIDs, subredditID)
+	}
+	if len(multireddit.SubredditIDs) > cfg.MaxSubreddits {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: fmt.Sprintf("a multireddit can have at most %d subreddits", cfg.MaxSubreddits)})
+		return false
+	}
+
+	existing, err := e.store.GetUserMultireddits(multireddit.OwnerID)
+	if err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error()})
+		return false
+	}
+	others := 0
+	for _, other := range existing {
+		if other.ID == multireddit.ID {
+			continue
+		}
+		others++
+		if strings.EqualFold(other.Name, multireddit.Name) {
+			e.metrics.RecordError()
+			context.Respond(&pb.ErrorResponse{Error: "you already have a multireddit with this name", Code: pb.ErrorCode_DUPLICATE})
+			return false
+		}
+	}
+	if others >= cfg.MaxPerUser {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: fmt.Sprintf("users can have at most %d multireddits", cfg.MaxPerUser)})
+		return false
+	}
+	return true
+}
+
+// ownedMultireddit loads a multireddit userID owns, responding with
+// NOT_FOUND or FORBIDDEN and returning nil otherwise
+func (e *EngineActor) ownedMultireddit(context actor.Context, id, userID string) *models.Multireddit {
+	multireddit, err := e.store.GetMultireddit(id)
+	if err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error(), Code: pb.ErrorCode_NOT_FOUND})
+		return nil
+	}
+	if multireddit.OwnerID != userID {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: "only the owner can change a multireddit", Code: pb.ErrorCode_FORBIDDEN})
+		return nil
+	}
+	return multireddit
+}
+
+// viewableMultireddit loads a multireddit userID owns or that is public,
+// responding with NOT_FOUND or FORBIDDEN and returning nil otherwise
+func (e *EngineActor) viewableMultireddit(context actor.Context, id, userID string) *models.Multireddit {
+	multireddit, err := e.store.GetMultireddit(id)
+	if err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error(), Code: pb.ErrorCode_NOT_FOUND})
+		return nil
+	}
+	if !multireddit.Public && multireddit.OwnerID != userID {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: "this multireddit is private", Code: pb.ErrorCode_FORBIDDEN})
+		return nil
+	}
+	return multireddit
+}
+
+// multiredditMessage converts a multireddit for userID, leaving out
+// subreddits they cannot read
+func (e *EngineActor) multiredditMessage(multireddit *models.Multireddit, userID string) *pb.MultiredditMessage {
+	message := &pb.MultiredditMessage{
+		Id:           multireddit.ID,
+		OwnerId:      multireddit.OwnerID,
+		Name:         multireddit.Name,
+		Description:  multireddit.Description,
+		SubredditIds: make([]string, 0, len(multireddit.SubredditIDs)),
+		Public:       multireddit.Public,
+		CreatedAt:    multireddit.Created,
+		UpdatedAt:    multireddit.Updated,
+	}
+	for _, subredditID := range multireddit.SubredditIDs {
+		if e.readable(subredditID, userID) {
+			message.SubredditIds = append(message.SubredditIds, subredditID)
+		}
+	}
+	return message
+}
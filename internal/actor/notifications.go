@@ -0,0 +1,129 @@
+package actor
+
+import (
+	"github.com/asynkron/protoactor-go/actor"
+	"reddit-clone/internal/models"
+	"reddit-clone/pkg/utils"
+	"regexp"
+	"time"
+)
+
+// mentionPattern matches u/username mentions in post and comment text
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w/])/?u/([A-Za-z0-9_-]{3,20})\b`)
+
+// notify is sent by the engine to deliver a notification to a user's actor
+type notify struct {
+	Notification *models.Notification
+}
+
+// mentions returns the distinct usernames mentioned in text
+func mentions(text string) []string {
+	seen := make(map[string]bool)
+	usernames := make([]string, 0)
+	for _, match := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			usernames = append(usernames, match[1])
+		}
+	}
+	return usernames
+}
+
+// sendNotification activates the recipient's actor and hands it the
+// notification. Nothing is sent if the recipient cannot read the subreddit
+// it comes from or has blocked the user who caused it.
+func (e *EngineActor) sendNotification(context actor.Context, notification *models.Notification) {
+	if notification.SubredditID != "" && !e.readable(notification.SubredditID, notification.UserID) {
+		return
+	}
+	if e.hasBlocked(notification.UserID, notification.ActorID) {
+		return
+	}
+
+	pid, err := e.userActor(context, notification.UserID)
+	if err != nil {
+		return
+	}
+	notification.ID = utils.GenerateID()
+	notification.Created = time.Now().Unix()
+	context.Send(pid, &notify{Notification: notification})
+}
+
+// notifyComment tells the parent's author about a reply and any mentioned users about the mention
+func (e *EngineActor) notifyComment(context actor.Context, comment *models.Comment, post *models.Post) {
+	notified := map[string]bool{comment.AuthorID: true}
+
+	reply := &models.Notification{
+		ActorID:   comment.AuthorID,
+		PostID:    comment.PostID,
+		CommentID: comment.ID,
+		Snippet:   snippet(comment.Content),
+	}
+	if post != nil {
+		reply.SubredditID = post.SubredditID
+	}
+	if comment.ParentID != "" {
+		if parent, err := e.store.GetComment(comment.ParentID); err == nil {
+			reply.UserID = parent.AuthorID
+			reply.Type = models.NotifyCommentReply
+		}
+	} else if post != nil {
+		reply.UserID = post.AuthorID
+		reply.Type = models.NotifyPostReply
+	}
+	if reply.UserID != "" && !notified[reply.UserID] {
+		notified[reply.UserID] = true
+		e.sendNotification(context, reply)
+	}
+
+	e.notifyMentions(context, comment.Content, notified, func() *models.Notification {
+		mention := *reply
+		mention.Type = models.NotifyMention
+		return &mention
+	})
+}
+
+// notifyPost tells users mentioned in a post's title or content
+func (e *EngineActor) notifyPost(context actor.Context, post *models.Post) {
+	notified := map[string]bool{post.AuthorID: true}
+	e.notifyMentions(context, post.Title+" "+post.Content, notified, func() *models.Notification {
+		return &models.Notification{
+			Type:        models.NotifyMention,
+			ActorID:     post.AuthorID,
+			SubredditID: post.SubredditID,
+			PostID:      post.ID,
+			Snippet:     snippet(post.Title),
+		}
+	})
+}
+
+// hasBlocked reports whether userID has blocked otherID
+func (e *EngineActor) hasBlocked(userID, otherID string) bool {
+	if userID == otherID {
+		return false
+	}
+	blocked, err := e.store.GetBlockedUsers(userID)
+	if err != nil {
+		return false
+	}
+	for _, blockedID := range blocked {
+		if blockedID == otherID {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *EngineActor) notifyMentions(context actor.Context, text string, notified map[string]bool, build func() *models.Notification) {
+	for _, username := range mentions(text) {
+		user, err := e.store.GetUserByUsername(username)
+		if err != nil || notified[user.ID] {
+			continue
+		}
+		notified[user.ID] = true
+
+		notification := build()
+		notification.UserID = user.ID
+		e.sendNotification(context, notification)
+	}
+}
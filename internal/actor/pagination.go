@@ -0,0 +1,34 @@
+package actor
+
+const (
+	defaultPageSize = 25
+	maxPageSize     = 100
+)
+
+// paginate returns up to limit items following the item whose ID is after,
+// and the cursor to pass as after for the next page ("" on the last page).
+func paginate[T any](items []T, id func(T) string, after string, limit int32) ([]T, string) {
+	size := int(limit)
+	if size <= 0 {
+		size = defaultPageSize
+	}
+	if size > maxPageSize {
+		size = maxPageSize
+	}
+
+	start := 0
+	if after != "" {
+		for i, item := range items {
+			if id(item) == after {
+				start = i + 1
+				break
+			}
+		}
+	}
+
+	end := start + size
+	if end >= len(items) {
+		return items[start:], ""
+	}
+	return items[start:end], id(items[end-1])
+}
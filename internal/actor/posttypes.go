@@ -0,0 +1,285 @@
+package actor
+
+import (
+	"errors"
+	"fmt"
+	"github.com/asynkron/protoactor-go/actor"
+	"net/http"
+	"net/url"
+	pb "reddit-clone/api/proto/generated"
+	"reddit-clone/internal/common"
+	"reddit-clone/internal/models"
+	"reddit-clone/pkg/utils"
+	"strings"
+	"time"
+)
+
+// preparePost validates the type-specific fields of a new post and copies them onto it
+func (e *EngineActor) preparePost(post *models.Post, msg *pb.PostMessage) error {
+	post.Type = models.PostType(msg.Type)
+
+	switch post.Type {
+	case models.PostText:
+		return nil
+	case models.PostLink:
+		link, domain, err := parseLink(msg.Url)
+		if err != nil {
+			return err
+		}
+		post.URL, post.Domain = link, domain
+		return nil
+	case models.PostPoll:
+		poll, err := e.newPoll(msg.Poll, time.Unix(post.Created, 0))
+		if err != nil {
+			return err
+		}
+		post.Poll = poll
+		return nil
+	case models.PostImage:
+		if err := e.checkImages(msg.ImageIds, post.AuthorID); err != nil {
+			return err
+		}
+		post.ImageIDs = append([]string(nil), msg.ImageIds...)
+		return nil
+	}
+	return errors.New("unknown post type")
+}
+
+// parseLink validates an http(s) URL and returns it normalized, along with its domain
+func parseLink(raw string) (string, string, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return "", "", errors.New("link posts need a valid http or https URL")
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	u.Fragment = ""
+	u.Path = strings.TrimSuffix(u.Path, "/")
+
+	domain := strings.TrimPrefix(u.Hostname(), "www.")
+	return u.String(), domain, nil
+}
+
+func (e *EngineActor) newPoll(msg *pb.PollMessage, now time.Time) (*models.Poll, error) {
+	cfg := e.config.Posts
+	if msg == nil || len(msg.Options) < cfg.MinPollOptions || len(msg.Options) > cfg.MaxPollOptions {
+		return nil, fmt.Errorf("polls need between %d and %d options", cfg.MinPollOptions, cfg.MaxPollOptions)
+	}
+
+	duration := cfg.DefaultPollDuration
+	if msg.DurationSeconds > 0 {
+		duration = time.Duration(msg.DurationSeconds) * time.Second
+	}
+	if duration > cfg.MaxPollDuration {
+		return nil, fmt.Errorf("polls can run for at most %s", cfg.MaxPollDuration)
+	}
+
+	poll := &models.Poll{
+		Options: make([]models.PollOption, 0, len(msg.Options)),
+		Closes:  now.Add(duration).Unix(),
+		Voters:  make(map[string]int),
+	}
+	for _, option := range msg.Options {
+		text := strings.TrimSpace(option.Text)
+		if text == "" {
+			return nil, errors.New("poll options cannot be empty")
+		}
+		poll.Options = append(poll.Options, models.PollOption{Text: text})
+	}
+	return poll, nil
+}
+
+// checkImages makes sure every image exists and was uploaded by the post's author
+func (e *EngineActor) checkImages(imageIDs []string, authorID string) error {
+	if len(imageIDs) == 0 || len(imageIDs) > e.config.Posts.MaxImagesPerPost {
+		return fmt.Errorf("image posts need between 1 and %d images", e.config.Posts.MaxImagesPerPost)
+	}
+	for _, imageID := range imageIDs {
+		blob, err := e.store.GetBlob(imageID)
+		if err != nil {
+			return err
+		}
+		if blob.OwnerID != authorID {
+			return errors.New("images must be uploaded by the post author")
+		}
+	}
+	return nil
+}
+
+func (e *EngineActor) handlePollVote(context actor.Context, msg *pb.PollVoteMessage) {
+	start := time.Now()
+
+	if !e.authenticate(context, msg.UserId, msg.SessionId) {
+		return
+	}
+	post, err := e.store.GetPost(msg.PostId)
+	if err != nil || post.Poll == nil || post.Removed || post.Filtered {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: "poll not found", Code: pb.ErrorCode_NOT_FOUND})
+		return
+	}
+	if !post.Poll.Open(time.Now().Unix()) {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: "poll is closed"})
+		return
+	}
+
+	if !e.checkBanned(context, post.SubredditID, msg.UserId) {
+		return
+	}
+	if !e.checkAccess(context, post.SubredditID, msg.UserId, false) {
+		return
+	}
+	if !e.allow(context, msg.UserId, common.VoteAction, post.SubredditID) {
+		return
+	}
+
+	err = e.store.VotePoll(msg.PostId, msg.UserId, int(msg.Option))
+	if err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	e.metrics.RecordRequest(time.Since(start).Seconds())
+	context.Respond(&pb.SuccessResponse{Message: "Poll vote recorded successfully"})
+}
+
+func (e *EngineActor) handleOtherDiscussions(context actor.Context, msg *pb.OtherDiscussionsMessage) {
+	start := time.Now()
+
+	link, excludeID := msg.Url, msg.PostId
+	if msg.PostId != "" {
+		post, err := e.store.GetPost(msg.PostId)
+		if err != nil || !e.readable(post.SubredditID, msg.UserId) {
+			e.metrics.RecordError()
+			context.Respond(&pb.ErrorResponse{Error: "post not found", Code: pb.ErrorCode_NOT_FOUND})
+			return
+		}
+		link = post.URL
+	} else if normalized, _, err := parseLink(msg.Url); err == nil {
+		link = normalized
+	}
+	if link == "" {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: "not a link post"})
+		return
+	}
+
+	posts, err := e.store.GetPostsByURL(link)
+	if err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	// Newest first
+	discussions := make([]*models.Post, 0, len(posts))
+	for i := len(posts) - 1; i >= 0; i-- {
+		post := posts[i]
+		if post.ID == excludeID || post.Removed || post.Filtered || !e.readable(post.SubredditID, msg.UserId) {
+			continue
+		}
+		discussions = append(discussions, post)
+	}
+
+	page, next := paginate(discussions, func(p *models.Post) string { return p.ID }, msg.After, msg.Limit)
+
+	response := &pb.FeedResponse{
+		Posts: make([]*pb.PostMessage, 0, len(page)),
+		Next:  next,
+	}
+	for _, post := range page {
+		response.Posts = append(response.Posts, postMessage(post))
+	}
+
+	e.metrics.RecordRequest(time.Since(start).Seconds())
+	context.Respond(response)
+}
+
+func (e *EngineActor) handleUploadImage(context actor.Context, msg *pb.UploadImageMessage) {
+	start := time.Now()
+
+	if !e.authenticate(context, msg.UploaderId, msg.SessionId) {
+		return
+	}
+	if _, err := e.store.GetUser(msg.UploaderId); err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error(), Code: pb.ErrorCode_NOT_FOUND})
+		return
+	}
+	if len(msg.Data) == 0 || len(msg.Data) > e.config.Posts.MaxImageBytes {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: fmt.Sprintf("images must be between 1 and %d bytes", e.config.Posts.MaxImageBytes)})
+		return
+	}
+
+	// Trust the bytes rather than a client-supplied content type
+	contentType := http.DetectContentType(msg.Data)
+	if !strings.HasPrefix(contentType, "image/") {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: "unsupported image type " + contentType})
+		return
+	}
+
+	blob := &models.Blob{
+		ID:          utils.GenerateID(),
+		OwnerID:     msg.UploaderId,
+		ContentType: contentType,
+		Data:        msg.Data,
+		Created:     time.Now().Unix(),
+	}
+	if err := e.store.SaveBlob(blob); err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	e.metrics.RecordRequest(time.Since(start).Seconds())
+	context.Respond(&pb.UploadImageResponse{ImageId: blob.ID, ContentType: blob.ContentType})
+}
+
+func (e *EngineActor) handleGetImage(context actor.Context, msg *pb.GetImageMessage) {
+	start := time.Now()
+
+	blob, err := e.store.GetBlob(msg.ImageId)
+	if err != nil || !e.imageVisible(blob, msg.UserId) {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: "image not found", Code: pb.ErrorCode_NOT_FOUND})
+		return
+	}
+
+	e.metrics.RecordRequest(time.Since(start).Seconds())
+	context.Respond(&pb.ImageResponse{ImageId: blob.ID, ContentType: blob.ContentType, Data: blob.Data})
+}
+
+// imageVisible reports whether userID uploaded the image or can see a post
+// showing it, under the same rules as GetPost
+func (e *EngineActor) imageVisible(blob *models.Blob, userID string) bool {
+	if blob.OwnerID == userID {
+		return true
+	}
+	posts, err := e.store.GetImagePosts(blob.ID)
+	if err != nil {
+		return false
+	}
+	for _, post := range posts {
+		if !post.Removed && !post.Filtered && e.readable(post.SubredditID, userID) {
+			return true
+		}
+	}
+	return false
+}
+
+func pollMessage(poll *models.Poll) *pb.PollMessage {
+	protoPoll := &pb.PollMessage{
+		Options:    make([]*pb.PollOptionMessage, 0, len(poll.Options)),
+		ClosesAt:   poll.Closes,
+		TotalVotes: int32(len(poll.Voters)),
+	}
+	for _, option := range poll.Options {
+		protoPoll.Options = append(protoPoll.Options, &pb.PollOptionMessage{Text: option.Text, Votes: option.Votes})
+	}
+	return protoPoll
+}
@@ -0,0 +1,100 @@
+package actor
+
+import (
+	"github.com/asynkron/protoactor-go/actor"
+	pb "reddit-clone/api/proto/generated"
+	"sort"
+	"time"
+)
+
+// profileEntry is a post or comment on a user's profile, with the fields it is sorted by
+type profileEntry struct {
+	item    *pb.ProfileItem
+	id      string
+	karma   int32
+	created int64
+}
+
+func (e *EngineActor) handleGetUserProfile(context actor.Context, msg *pb.GetUserProfileMessage) {
+	start := time.Now()
+
+	user, err := e.store.GetUser(msg.UserId)
+	if err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error(), Code: pb.ErrorCode_NOT_FOUND})
+		return
+	}
+	posts, err := e.store.GetUserPosts(msg.UserId)
+	if err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error()})
+		return
+	}
+	comments, err := e.store.GetUserComments(msg.UserId)
+	if err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	response := &pb.UserProfileResponse{
+		UserId:            user.ID,
+		Username:          user.Username,
+		CreatedAt:         user.Created,
+		AccountAgeSeconds: time.Now().Unix() - user.Created,
+		Karma:             user.Karma,
+	}
+
+	// Karma and counts include everything; the listing only what the viewer may see
+	entries := make([]profileEntry, 0, len(posts)+len(comments))
+	for _, post := range posts {
+		response.PostKarma += post.Karma
+		response.PostCount++
+		if msg.Section == pb.ProfileSection_PROFILE_COMMENTS ||
+			post.Removed || post.Filtered || !e.readable(post.SubredditID, msg.ViewerId) {
+			continue
+		}
+		entries = append(entries, profileEntry{
+			item:    &pb.ProfileItem{Post: postMessage(post)},
+			id:      post.ID,
+			karma:   post.Karma,
+			created: post.Created,
+		})
+	}
+	for _, comment := range comments {
+		response.CommentKarma += comment.Karma
+		response.CommentCount++
+		if msg.Section == pb.ProfileSection_PROFILE_POSTS ||
+			comment.Removed || comment.Filtered || !e.readable(e.targetSubreddit(comment.ID), msg.ViewerId) {
+			continue
+		}
+		entries = append(entries, profileEntry{
+			item:    &pb.ProfileItem{Comment: commentMessage(comment)},
+			id:      comment.ID,
+			karma:   comment.Karma,
+			created: comment.Created,
+		})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if msg.Sort == pb.ProfileSort_PROFILE_TOP && a.karma != b.karma {
+			return a.karma > b.karma
+		}
+		if a.created != b.created {
+			return a.created > b.created
+		}
+		return a.id < b.id
+	})
+
+	page, next := paginate(entries, func(entry profileEntry) string { return entry.id }, msg.After, msg.Limit)
+
+	response.Items = make([]*pb.ProfileItem, 0, len(page))
+	for _, entry := range page {
+		response.Items = append(response.Items, entry.item)
+	}
+	response.Next = next
+
+	e.metrics.RecordRequest(time.Since(start).Seconds())
+	context.Respond(response)
+}
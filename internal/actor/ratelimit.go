@@ -0,0 +1,55 @@
+package actor
+
+import (
+	"github.com/asynkron/protoactor-go/actor"
+	pb "reddit-clone/api/proto/generated"
+	"reddit-clone/internal/common"
+	"reddit-clone/internal/ratelimit"
+	"time"
+)
+
+// allow applies the per-user and, when subredditID is set, per-subreddit limits
+// for action. If the user is over either limit it responds with RATE_LIMITED
+// and a retry-after hint and returns false.
+func (e *EngineActor) allow(context actor.Context, userID string, action common.ActionType, subredditID string) bool {
+	cfg := &e.config.RateLimit
+	if !cfg.Enabled {
+		return true
+	}
+
+	strict := false
+	if user, err := e.store.GetUser(userID); err == nil {
+		age := time.Since(time.Unix(user.Created, 0))
+		strict = age < cfg.NewAccountAge || user.Karma < cfg.LowKarma
+	}
+
+	// Both limits are checked before either is charged, so a request refused
+	// by one does not use up the other
+	var requests []ratelimit.Request
+	if limit, exists := cfg.PerUser[string(action)]; exists {
+		if strict {
+			limit = cfg.Strict(limit)
+		}
+		requests = append(requests, ratelimit.Request{Key: string(action) + ":" + userID, Limit: limit})
+	}
+	if limit, exists := cfg.PerSubreddit[string(action)]; exists && subredditID != "" {
+		if strict {
+			limit = cfg.Strict(limit)
+		}
+		requests = append(requests, ratelimit.Request{Key: string(action) + ":" + subredditID + ":" + userID, Limit: limit})
+	}
+
+	ok, retryAfter := e.limiter.AllowAll(requests...)
+	if ok {
+		return true
+	}
+
+	e.metrics.RecordError()
+	e.metrics.RecordRateLimited(string(action))
+	context.Respond(&pb.ErrorResponse{
+		Error:        "rate limit exceeded",
+		Code:         pb.ErrorCode_RATE_LIMITED,
+		RetryAfterMs: retryAfter.Milliseconds(),
+	})
+	return false
+}
@@ -0,0 +1,113 @@
+package actor
+
+import (
+	"github.com/asynkron/protoactor-go/actor"
+	pb "reddit-clone/api/proto/generated"
+	"reddit-clone/internal/models"
+	"time"
+)
+
+// rebuildRecommendations replays memberships and the latest vote of each
+// user on each post and comment after a restart
+func (e *EngineActor) rebuildRecommendations(subreddits []*models.Subreddit) error {
+	for _, subreddit := range subreddits {
+		for userID := range subreddit.Members {
+			e.recommender.Join(userID, subreddit.ID)
+		}
+	}
+
+	posts, err := e.store.GetPostsSince(0)
+	if err != nil {
+		return err
+	}
+	postSubreddits := make(map[string]string, len(posts))
+	for _, post := range posts {
+		postSubreddits[post.ID] = post.SubredditID
+		if err := e.replayVotes(post.ID, post.SubredditID); err != nil {
+			return err
+		}
+	}
+
+	comments, err := e.store.GetCommentsSince(0)
+	if err != nil {
+		return err
+	}
+	for _, comment := range comments {
+		if err := e.replayVotes(comment.ID, postSubreddits[comment.PostID]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *EngineActor) replayVotes(targetID, subredditID string) error {
+	votes, err := e.store.GetVotes(targetID)
+	if err != nil {
+		return err
+	}
+	for userID, isUpvote := range votes {
+		e.recommender.Vote(userID, subredditID, voteValue(isUpvote))
+	}
+	return nil
+}
+
+func voteValue(isUpvote bool) int {
+	if isUpvote {
+		return 1
+	}
+	return -1
+}
+
+func (e *EngineActor) handleGetRecommendations(context actor.Context, msg *pb.GetRecommendationsMessage) {
+	start := time.Now()
+
+	if _, err := e.store.GetUser(msg.UserId); err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error(), Code: pb.ErrorCode_NOT_FOUND})
+		return
+	}
+
+	limit := int(msg.Limit)
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+
+	// Only suggest subreddits the user could actually read and take part in
+	subreddits := make(map[string]*models.Subreddit)
+	allow := func(subredditID string) bool {
+		subreddit, err := e.store.GetSubreddit(subredditID)
+		if err != nil || !canRead(subreddit, msg.UserId) {
+			return false
+		}
+		if ban, banned := subreddit.Bans[msg.UserId]; banned && ban.Active(start.Unix()) {
+			return false
+		}
+		subreddits[subredditID] = subreddit
+		return true
+	}
+
+	response := &pb.RecommendationsResponse{}
+	recommendations := e.recommender.Recommend(msg.UserId, limit, allow)
+	if len(recommendations) == 0 {
+		recommendations = e.recommender.Popular(msg.UserId, limit, allow)
+		response.Popular = true
+	}
+
+	response.Recommendations = make([]*pb.RecommendationMessage, 0, len(recommendations))
+	for _, recommendation := range recommendations {
+		subreddit := subreddits[recommendation.SubredditID]
+		response.Recommendations = append(response.Recommendations, &pb.RecommendationMessage{
+			SubredditId: subreddit.ID,
+			Name:        subreddit.Name,
+			Description: subreddit.Description,
+			Members:     int32(len(subreddit.Members)),
+			Score:       recommendation.Score,
+		})
+	}
+
+	e.metrics.RecordRequest(time.Since(start).Seconds())
+	context.Respond(response)
+}
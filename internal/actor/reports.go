@@ -0,0 +1,201 @@
+package actor
+
+import (
+	"fmt"
+	"github.com/asynkron/protoactor-go/actor"
+	pb "reddit-clone/api/proto/generated"
+	"reddit-clone/internal/models"
+	"sort"
+	"strings"
+	"time"
+)
+
+func (e *EngineActor) handleSetReportReasons(context actor.Context, msg *pb.SetReportReasonsMessage) {
+	start := time.Now()
+
+	if e.requireModerator(context, msg.SubredditId, msg.ModeratorId, msg.SessionId) == nil {
+		return
+	}
+
+	reasons := make([]string, 0, len(msg.Reasons))
+	for _, reason := range msg.Reasons {
+		if reason = strings.TrimSpace(reason); reason != "" {
+			reasons = append(reasons, reason)
+		}
+	}
+
+	err := e.store.SetReportReasons(msg.SubredditId, reasons)
+	if err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	e.logModAction(msg.SubredditId, msg.ModeratorId, models.ModRuleChange, msg.SubredditId, "report reasons: "+strings.Join(reasons, ", "))
+
+	e.metrics.RecordRequest(time.Since(start).Seconds())
+	context.Respond(&pb.SuccessResponse{Message: "Report reasons updated successfully"})
+}
+
+func (e *EngineActor) handleReport(context actor.Context, msg *pb.ReportMessage) {
+	start := time.Now()
+
+	if !e.authenticate(context, msg.ReporterId, msg.SessionId) {
+		return
+	}
+	reason := strings.TrimSpace(msg.Reason)
+	if reason == "" || len(reason) > e.config.Reports.MaxReasonLength {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: fmt.Sprintf("report reason must be 1 to %d characters", e.config.Reports.MaxReasonLength)})
+		return
+	}
+
+	if _, err := e.store.GetUser(msg.ReporterId); err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error(), Code: pb.ErrorCode_NOT_FOUND})
+		return
+	}
+	target, err := e.findTarget(msg.TargetId, models.TargetType(msg.TargetType))
+	if err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error(), Code: pb.ErrorCode_NOT_FOUND})
+		return
+	}
+	if !e.checkAccess(context, target.SubredditID, msg.ReporterId, false) {
+		return
+	}
+
+	// Group free-text reasons that match one of the subreddit's reasons
+	if subreddit, err := e.store.GetSubreddit(target.SubredditID); err == nil {
+		for _, defined := range subreddit.ReportReasons {
+			if strings.EqualFold(defined, reason) {
+				reason = defined
+				break
+			}
+		}
+	}
+
+	item, err := e.store.AddReport(&models.QueueItem{
+		TargetID:    target.ID,
+		TargetType:  target.Type,
+		SubredditID: target.SubredditID,
+		Created:     time.Now().Unix(),
+	}, msg.ReporterId, reason)
+	if err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	// Hide heavily reported content until a moderator looks at it, unless a
+	// moderator has already approved it or AutoModerator dealt with it
+	hidden := e.autoModerate(context, target, item.ReportCount())
+	threshold := e.config.Reports.FilterThreshold
+	if threshold > 0 && item.ReportCount() >= threshold && !target.Approved && !target.Filtered && !hidden {
+		if err := e.store.FilterContent(item, "reported by multiple users"); err != nil {
+			e.metrics.RecordError()
+		}
+	}
+
+	e.metrics.RecordRequest(time.Since(start).Seconds())
+	context.Respond(&pb.SuccessResponse{Message: "Report submitted successfully"})
+}
+
+func (e *EngineActor) handleGetModQueue(context actor.Context, msg *pb.GetModQueueMessage) {
+	start := time.Now()
+
+	if e.requireModerator(context, msg.SubredditId, msg.ModeratorId, msg.SessionId) == nil {
+		return
+	}
+
+	items, err := e.store.GetModQueue(msg.SubredditId)
+	if err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	// Most reported first, then oldest first
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].ReportCount() != items[j].ReportCount() {
+			return items[i].ReportCount() > items[j].ReportCount()
+		}
+		if items[i].Created != items[j].Created {
+			return items[i].Created < items[j].Created
+		}
+		return items[i].TargetID < items[j].TargetID
+	})
+
+	page, next := paginate(items, func(item *models.QueueItem) string { return item.TargetID }, msg.After, msg.Limit)
+
+	response := &pb.ModQueueResponse{
+		Items: make([]*pb.ModQueueItemMessage, 0, len(page)),
+		Next:  next,
+	}
+	for _, item := range page {
+		queued := &pb.ModQueueItemMessage{
+			TargetId:     item.TargetID,
+			TargetType:   pb.TargetType(item.TargetType),
+			ReportCount:  int32(item.ReportCount()),
+			Filtered:     item.Filtered,
+			FilterReason: item.FilterReason,
+		}
+		if target, err := e.findTarget(item.TargetID, item.TargetType); err == nil {
+			queued.AuthorId = target.AuthorID
+			queued.Title = target.Title
+			queued.Content = target.Content
+		}
+		for reason, count := range item.Reasons {
+			queued.Reasons = append(queued.Reasons, &pb.ReportReasonCount{Reason: reason, Count: int32(count)})
+		}
+		sort.Slice(queued.Reasons, func(i, j int) bool {
+			return queued.Reasons[i].Count > queued.Reasons[j].Count
+		})
+		response.Items = append(response.Items, queued)
+	}
+
+	e.metrics.RecordRequest(time.Since(start).Seconds())
+	context.Respond(response)
+}
+
+func (e *EngineActor) handleResolveReport(context actor.Context, msg *pb.ResolveReportMessage) {
+	start := time.Now()
+
+	target, err := e.findTarget(msg.TargetId, models.TargetType(msg.TargetType))
+	if err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error(), Code: pb.ErrorCode_NOT_FOUND})
+		return
+	}
+
+	if e.requireModerator(context, target.SubredditID, msg.ModeratorId, msg.SessionId) == nil {
+		return
+	}
+
+	var action models.ModAction
+	switch msg.Resolution {
+	case pb.ReportResolution_RESOLVE_DISMISS:
+		action = models.ModDismissReports
+		err = e.store.ClearReports(target.ID)
+	case pb.ReportResolution_RESOLVE_REMOVE:
+		action = models.ModRemove
+		err = e.setRemoved(target, true)
+	case pb.ReportResolution_RESOLVE_APPROVE:
+		action = models.ModApprove
+		err = e.setRemoved(target, false)
+	default:
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: "unknown report resolution"})
+		return
+	}
+	if err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	e.logModAction(target.SubredditID, msg.ModeratorId, action, target.ID, msg.Reason)
+
+	e.metrics.RecordRequest(time.Since(start).Seconds())
+	context.Respond(&pb.SuccessResponse{Message: "Report resolved successfully"})
+}
@@ -0,0 +1,88 @@
+package actor
+
+import (
+	"fmt"
+	"github.com/asynkron/protoactor-go/actor"
+	"hash/fnv"
+	pb "reddit-clone/api/proto/generated"
+	"reddit-clone/internal/dedup"
+	"reddit-clone/internal/models"
+	"strings"
+	"time"
+)
+
+// checkRepost fingerprints the post and flags it if it duplicates a recent
+// post. If the subreddit's policy rejects the duplicate it responds with
+// DUPLICATE and returns false.
+func (e *EngineActor) checkRepost(context actor.Context, post *models.Post) bool {
+	if rejected := e.fingerprintPost(post); rejected != nil {
+		e.metrics.RecordError()
+		context.Respond(rejected)
+		return false
+	}
+	return true
+}
+
+// fingerprintPost is checkRepost without a requester: it returns the error
+// to report if the duplicate is rejected, or nil
+func (e *EngineActor) fingerprintPost(post *models.Post) *pb.ErrorResponse {
+	fingerprint := dedup.NewFingerprint(post.Title, e.fingerprintContent(post))
+	post.ContentHash = fingerprint.Hash
+	post.SimHash = fingerprint.SimHash
+
+	local, other := e.reposts.Check(post.SubredditID, fingerprint, time.Now())
+	match, scope := local, "subreddit"
+	if match == nil {
+		match, scope = other, "global"
+	}
+	if match == nil {
+		return nil
+	}
+
+	matchType := "near"
+	if match.Exact {
+		matchType = "exact"
+	}
+	e.metrics.RecordRepost(matchType, scope)
+
+	policy := models.RepostFlag
+	if subreddit, err := e.store.GetSubreddit(post.SubredditID); err == nil {
+		policy = subreddit.RepostPolicy
+	}
+	if policy == models.RepostRejectAny || (policy == models.RepostRejectLocal && local != nil) {
+		return &pb.ErrorResponse{
+			Error: "duplicate of post " + match.PostID,
+			Code:  pb.ErrorCode_DUPLICATE,
+		}
+	}
+
+	post.IsRepost = true
+	post.DuplicateOf = match.PostID
+	return nil
+}
+
+// fingerprintContent is the text a post is compared on besides its title.
+// Link posts add their target, image posts a hash of each image's bytes (so
+// the same picture uploaded twice still matches) and polls their options.
+func (e *EngineActor) fingerprintContent(post *models.Post) string {
+	parts := []string{post.Content, post.URL}
+	for _, id := range post.ImageIDs {
+		if blob, err := e.store.GetBlob(id); err == nil {
+			h := fnv.New64a()
+			h.Write(blob.Data)
+			id = fmt.Sprintf("%x", h.Sum64())
+		}
+		parts = append(parts, "image"+id)
+	}
+	if post.Poll != nil {
+		for _, option := range post.Poll.Options {
+			parts = append(parts, option.Text)
+		}
+	}
+	return strings.TrimSpace(strings.Join(parts, " "))
+}
+
+// indexPost makes a stored post visible to later repost checks
+func (e *EngineActor) indexPost(post *models.Post) {
+	e.reposts.Add(post.ID, post.SubredditID, dedup.Fingerprint{Hash: post.ContentHash, SimHash: post.SimHash}, post.Created)
+}
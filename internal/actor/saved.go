@@ -0,0 +1,170 @@
+package actor
+
+import (
+	"github.com/asynkron/protoactor-go/actor"
+	pb "reddit-clone/api/proto/generated"
+	"reddit-clone/internal/models"
+	"time"
+)
+
+// itemSaved is sent by a user actor so the engine can count saves as for-you signals
+type itemSaved struct {
+	UserID   string
+	TargetID string
+	Saved    bool
+}
+
+// handleSave checks the user may see what they are saving before their actor
+// records it, so private content can't be probed or kept after losing access.
+func (e *EngineActor) handleSave(context actor.Context, msg *pb.SaveMessage) {
+	if !e.authenticate(context, msg.UserId, msg.SessionId) {
+		return
+	}
+	if !msg.Unsave && !e.readable(e.targetSubreddit(msg.TargetId), msg.UserId) {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: "item not found", Code: pb.ErrorCode_NOT_FOUND})
+		return
+	}
+	e.forwardToUser(context, msg.UserId)
+}
+
+func (u *UserActor) handleSave(context actor.Context, msg *pb.SaveMessage) {
+	start := time.Now()
+
+	if msg.Unsave {
+		if err := u.store.UnsaveItem(u.userID, msg.TargetId); err != nil {
+			u.metrics.RecordError()
+			context.Respond(&pb.ErrorResponse{Error: err.Error(), Code: pb.ErrorCode_NOT_FOUND})
+			return
+		}
+		context.Send(context.Parent(), &itemSaved{UserID: u.userID, TargetID: msg.TargetId})
+		u.metrics.RecordRequest(time.Since(start).Seconds())
+		context.Respond(&pb.SuccessResponse{Message: "Item unsaved successfully"})
+		return
+	}
+
+	item := &models.SavedItem{
+		UserID:     u.userID,
+		TargetID:   msg.TargetId,
+		TargetType: models.TargetType(msg.TargetType),
+		Saved:      time.Now().Unix(),
+	}
+
+	// Record the subreddit now so the saved list can be filtered without a lookup per item
+	if item.TargetType == models.TargetComment {
+		comment, err := u.store.GetComment(msg.TargetId)
+		if err != nil {
+			u.metrics.RecordError()
+			context.Respond(&pb.ErrorResponse{Error: err.Error(), Code: pb.ErrorCode_NOT_FOUND})
+			return
+		}
+		if post, err := u.store.GetPost(comment.PostID); err == nil {
+			item.SubredditID = post.SubredditID
+		}
+	} else {
+		post, err := u.store.GetPost(msg.TargetId)
+		if err != nil {
+			u.metrics.RecordError()
+			context.Respond(&pb.ErrorResponse{Error: err.Error(), Code: pb.ErrorCode_NOT_FOUND})
+			return
+		}
+		item.SubredditID = post.SubredditID
+	}
+
+	if err := u.store.SaveItem(item); err != nil {
+		u.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error(), Code: pb.ErrorCode_DUPLICATE})
+		return
+	}
+	context.Send(context.Parent(), &itemSaved{UserID: u.userID, TargetID: msg.TargetId, Saved: true})
+
+	u.metrics.RecordRequest(time.Since(start).Seconds())
+	context.Respond(&pb.SuccessResponse{Message: "Item saved successfully"})
+}
+
+// handleGetSaved is answered by the engine rather than the user actor,
+// because it reads posts and comments the engine is moderating.
+func (e *EngineActor) handleGetSaved(context actor.Context, msg *pb.GetSavedMessage) {
+	start := time.Now()
+
+	if !e.authenticate(context, msg.UserId, msg.SessionId) {
+		return
+	}
+	items, err := e.store.GetSavedItems(msg.UserId)
+	if err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	// Most recently saved first
+	matched := make([]*pb.SavedItemMessage, 0, len(items))
+	for i := len(items) - 1; i >= 0; i-- {
+		item := items[i]
+		if msg.SubredditId != "" && item.SubredditID != msg.SubredditId {
+			continue
+		}
+		if saved := e.savedItemMessage(item, msg.UserId); saved != nil {
+			matched = append(matched, saved)
+		}
+	}
+
+	page, next := paginate(matched, savedItemID, msg.After, msg.Limit)
+
+	e.metrics.RecordRequest(time.Since(start).Seconds())
+	context.Respond(&pb.SavedResponse{Items: page, Next: next})
+}
+
+// savedItemMessage loads the saved content, returning nil if it has since
+// been deleted or removed or the user can no longer read it.
+func (e *EngineActor) savedItemMessage(item *models.SavedItem, userID string) *pb.SavedItemMessage {
+	if !e.readable(item.SubredditID, userID) {
+		return nil
+	}
+
+	saved := &pb.SavedItemMessage{
+		TargetType: pb.TargetType(item.TargetType),
+		SavedAt:    item.Saved,
+	}
+
+	if item.TargetType == models.TargetComment {
+		comment, err := e.store.GetComment(item.TargetID)
+		if err != nil || comment.Removed || comment.Filtered {
+			return nil
+		}
+		saved.Comment = commentMessage(comment)
+		saved.Comment.Saved = true
+		return saved
+	}
+
+	post, err := e.store.GetPost(item.TargetID)
+	if err != nil || post.Removed || post.Filtered {
+		return nil
+	}
+	saved.Post = postMessage(post)
+	saved.Post.Saved = true
+	return saved
+}
+
+func savedItemID(item *pb.SavedItemMessage) string {
+	if item.Comment != nil {
+		return item.Comment.Id
+	}
+	return item.Post.Id
+}
+
+// savedSet returns the IDs of everything userID has saved
+func (e *EngineActor) savedSet(userID string) map[string]bool {
+	saved := make(map[string]bool)
+	if userID == "" {
+		return saved
+	}
+	items, err := e.store.GetSavedItems(userID)
+	if err != nil {
+		return saved
+	}
+	for _, item := range items {
+		saved[item.TargetID] = true
+	}
+	return saved
+}
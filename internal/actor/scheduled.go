@@ -0,0 +1,330 @@
+package actor
+
+import (
+	"errors"
+	"fmt"
+	"github.com/asynkron/protoactor-go/actor"
+	pb "reddit-clone/api/proto/generated"
+	"reddit-clone/internal/models"
+	"strings"
+	"time"
+)
+
+// schedulePost holds a checked post until its publish time. Scheduled posts
+// live in the store and are published from the engine's tick, so they
+// survive restarts and go live up to one tick late.
+func (e *EngineActor) schedulePost(context actor.Context, post *models.Post, msg *pb.PostMessage, start time.Time) {
+	cfg := e.config.Posts
+
+	publishAt := max(msg.PublishAt, start.Unix())
+	if publishAt > start.Add(cfg.MaxScheduleAhead).Unix() {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: fmt.Sprintf("posts can be scheduled at most %s ahead", cfg.MaxScheduleAhead)})
+		return
+	}
+	if msg.RepeatEverySeconds > 0 && !e.checkRepeat(context, post.SubredditID, post.AuthorID, msg.RepeatEverySeconds) {
+		return
+	}
+
+	scheduled := &models.ScheduledPost{
+		ID:        post.ID,
+		Post:      post,
+		PublishAt: publishAt,
+		Interval:  msg.RepeatEverySeconds,
+		Created:   start.Unix(),
+	}
+	if err := e.store.SchedulePost(scheduled); err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error(), Code: pb.ErrorCode_DUPLICATE})
+		return
+	}
+
+	e.metrics.RecordRequest(time.Since(start).Seconds())
+	context.Respond(&pb.SuccessResponse{Message: "Post scheduled successfully"})
+}
+
+// checkRepeat responds with an error and returns false unless userID may
+// schedule posts in the subreddit to recur every interval seconds
+func (e *EngineActor) checkRepeat(context actor.Context, subredditID, userID string, interval int64) bool {
+	subreddit, err := e.store.GetSubreddit(subredditID)
+	if err != nil || !isModerator(subreddit, userID) {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: "only moderators can schedule recurring posts", Code: pb.ErrorCode_FORBIDDEN})
+		return false
+	}
+	if minimum := e.config.Posts.MinRepeatInterval; time.Duration(interval)*time.Second < minimum {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: fmt.Sprintf("recurring posts can repeat at most every %s", minimum)})
+		return false
+	}
+	return true
+}
+
+// publishScheduled publishes every scheduled post that is due
+func (e *EngineActor) publishScheduled(context actor.Context, now time.Time) {
+	scheduled, err := e.store.GetScheduledPosts()
+	if err != nil {
+		e.metrics.RecordError()
+		return
+	}
+	for _, item := range scheduled {
+		if item.PublishAt > now.Unix() {
+			break // soonest first
+		}
+		e.publishScheduledPost(context, item, now)
+	}
+}
+
+func (e *EngineActor) publishScheduledPost(context actor.Context, item *models.ScheduledPost, now time.Time) {
+	post := scheduledOccurrence(item, now)
+
+	err := e.checkScheduledAuthor(item)
+	if err == nil && item.Interval == 0 {
+		// Recurring threads repeat on purpose, so only one-off posts are checked for reposts
+		if rejected := e.fingerprintPost(post); rejected != nil {
+			err = errors.New(rejected.Error)
+		}
+	}
+	if err == nil {
+		err = e.createPost(context, post)
+	}
+
+	notification := &models.Notification{
+		UserID:      item.Post.AuthorID,
+		ActorID:     item.Post.AuthorID,
+		SubredditID: post.SubredditID,
+		Snippet:     snippet(post.Title),
+	}
+	if err != nil {
+		e.metrics.RecordScheduledPost("dropped")
+		notification.Type = models.NotifyScheduledPostFailed
+	} else {
+		e.metrics.RecordScheduledPost("published")
+		notification.Type = models.NotifyScheduledPostPublished
+		notification.PostID = post.ID
+	}
+	e.sendNotification(context, notification)
+
+	if item.Interval == 0 || errors.Is(err, errNotModerator) {
+		if err := e.store.DeleteScheduledPost(item.ID); err != nil {
+			e.metrics.RecordError()
+		}
+		return
+	}
+
+	// Skip occurrences missed while the engine was down rather than publishing them all at once
+	next := *item
+	if err == nil {
+		next.Published++
+	}
+	for next.PublishAt <= now.Unix() {
+		next.PublishAt += next.Interval
+	}
+	if err := e.store.UpdateScheduledPost(&next); err != nil {
+		e.metrics.RecordError()
+	}
+}
+
+var errNotModerator = errors.New("author is no longer a moderator")
+
+// checkScheduledAuthor makes sure the author may still post where the post
+// was scheduled, and for recurring posts, is still a moderator there
+func (e *EngineActor) checkScheduledAuthor(item *models.ScheduledPost) error {
+	authorID := item.Post.AuthorID
+	subreddit, err := e.store.GetSubreddit(item.Post.SubredditID)
+	if err != nil {
+		return err
+	}
+	if ban, banned := subreddit.Bans[authorID]; banned && ban.Active(time.Now().Unix()) {
+		return errors.New("author is banned from this subreddit")
+	}
+	if item.Interval > 0 && !isModerator(subreddit, authorID) {
+		return errNotModerator
+	}
+	if !canPost(subreddit, authorID) {
+		return errors.New("author can no longer post in this subreddit")
+	}
+	return nil
+}
+
+// scheduledOccurrence copies the template into a post going live now.
+// Recurring posts get a numbered ID and "{date}" in the title is filled in.
+func scheduledOccurrence(item *models.ScheduledPost, now time.Time) *models.Post {
+	template := item.Post
+	post := *template
+	post.ID = item.ID
+	if item.Interval > 0 {
+		post.ID = fmt.Sprintf("%s-%d", item.ID, item.Published+1)
+	}
+	post.Title = strings.ReplaceAll(template.Title, "{date}", now.UTC().Format("2006-01-02"))
+	post.Created = now.Unix()
+	post.Votes = make(map[string]bool)
+
+	if template.Poll != nil {
+		// Each poll runs as long as the template's did from its own start
+		post.Poll = &models.Poll{
+			Options: append([]models.PollOption(nil), template.Poll.Options...),
+			Closes:  now.Unix() + template.Poll.Closes - template.Created,
+			Voters:  make(map[string]int),
+		}
+	}
+	if template.Flair != nil {
+		flair := *template.Flair
+		post.Flair = &flair
+	}
+	return &post
+}
+
+// canManageScheduled reports whether userID may see and change a scheduled post
+func (e *EngineActor) canManageScheduled(item *models.ScheduledPost, userID string) bool {
+	if item.Post.AuthorID == userID {
+		return true
+	}
+	subreddit, err := e.store.GetSubreddit(item.Post.SubredditID)
+	return err == nil && isModerator(subreddit, userID)
+}
+
+func (e *EngineActor) handleGetScheduledPosts(context actor.Context, msg *pb.GetScheduledPostsMessage) {
+	start := time.Now()
+
+	if !e.authenticate(context, msg.UserId, msg.SessionId) {
+		return
+	}
+	if msg.SubredditId != "" {
+		subreddit, err := e.store.GetSubreddit(msg.SubredditId)
+		if err != nil {
+			e.metrics.RecordError()
+			context.Respond(&pb.ErrorResponse{Error: err.Error(), Code: pb.ErrorCode_NOT_FOUND})
+			return
+		}
+		if !isModerator(subreddit, msg.UserId) {
+			e.metrics.RecordError()
+			context.Respond(&pb.ErrorResponse{Error: "only moderators can list a subreddit's scheduled posts", Code: pb.ErrorCode_FORBIDDEN})
+			return
+		}
+	}
+
+	scheduled, err := e.store.GetScheduledPosts()
+	if err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	response := &pb.ScheduledPostsResponse{Posts: make([]*pb.ScheduledPostMessage, 0)}
+	for _, item := range scheduled {
+		if msg.SubredditId != "" && item.Post.SubredditID != msg.SubredditId {
+			continue
+		}
+		if msg.SubredditId == "" && item.Post.AuthorID != msg.UserId {
+			continue
+		}
+		response.Posts = append(response.Posts, scheduledPostMessage(item))
+	}
+
+	e.metrics.RecordRequest(time.Since(start).Seconds())
+	context.Respond(response)
+}
+
+func (e *EngineActor) handleEditScheduledPost(context actor.Context, msg *pb.EditScheduledPostMessage) {
+	start := time.Now()
+
+	item, ok := e.managedScheduledPost(context, msg.Id, msg.UserId, msg.SessionId)
+	if !ok {
+		return
+	}
+
+	next := *item
+	template := *item.Post
+	if msg.Title != "" {
+		template.Title = msg.Title
+	}
+	if msg.Content != "" {
+		template.Content = msg.Content
+	}
+	next.Post = &template
+
+	if msg.PublishAt != 0 {
+		if msg.PublishAt <= start.Unix() || msg.PublishAt > start.Add(e.config.Posts.MaxScheduleAhead).Unix() {
+			e.metrics.RecordError()
+			context.Respond(&pb.ErrorResponse{Error: fmt.Sprintf("posts must be scheduled in the next %s", e.config.Posts.MaxScheduleAhead)})
+			return
+		}
+		next.PublishAt = msg.PublishAt
+	}
+	switch {
+	case msg.RepeatEverySeconds < 0:
+		next.Interval = 0
+	case msg.RepeatEverySeconds > 0:
+		if !e.checkRepeat(context, template.SubredditID, msg.UserId, msg.RepeatEverySeconds) {
+			return
+		}
+		// Occurrences are posted as the author, so a moderator cannot make a
+		// regular user's post recur
+		if subreddit, err := e.store.GetSubreddit(template.SubredditID); err != nil || !isModerator(subreddit, template.AuthorID) {
+			e.metrics.RecordError()
+			context.Respond(&pb.ErrorResponse{Error: "only posts by moderators can recur", Code: pb.ErrorCode_FORBIDDEN})
+			return
+		}
+		next.Interval = msg.RepeatEverySeconds
+	}
+
+	if err := e.store.UpdateScheduledPost(&next); err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error(), Code: pb.ErrorCode_NOT_FOUND})
+		return
+	}
+
+	e.metrics.RecordRequest(time.Since(start).Seconds())
+	context.Respond(&pb.SuccessResponse{Message: "Scheduled post updated successfully"})
+}
+
+func (e *EngineActor) handleCancelScheduledPost(context actor.Context, msg *pb.CancelScheduledPostMessage) {
+	start := time.Now()
+
+	if _, ok := e.managedScheduledPost(context, msg.Id, msg.UserId, msg.SessionId); !ok {
+		return
+	}
+	if err := e.store.DeleteScheduledPost(msg.Id); err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error(), Code: pb.ErrorCode_NOT_FOUND})
+		return
+	}
+
+	e.metrics.RecordRequest(time.Since(start).Seconds())
+	context.Respond(&pb.SuccessResponse{Message: "Scheduled post cancelled successfully"})
+}
+
+// managedScheduledPost authenticates userID and loads a scheduled post they
+// may change, responding with an error and returning false otherwise
+func (e *EngineActor) managedScheduledPost(context actor.Context, id, userID, sessionID string) (*models.ScheduledPost, bool) {
+	if !e.authenticate(context, userID, sessionID) {
+		return nil, false
+	}
+	item, err := e.store.GetScheduledPost(id)
+	if err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error(), Code: pb.ErrorCode_NOT_FOUND})
+		return nil, false
+	}
+	if !e.canManageScheduled(item, userID) {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: "only the author or a moderator can change a scheduled post", Code: pb.ErrorCode_FORBIDDEN})
+		return nil, false
+	}
+	return item, true
+}
+
+func scheduledPostMessage(item *models.ScheduledPost) *pb.ScheduledPostMessage {
+	post := postMessage(item.Post)
+	post.Id = item.ID
+	post.PublishAt = item.PublishAt
+	post.RepeatEverySeconds = item.Interval
+	return &pb.ScheduledPostMessage{
+		Id:                 item.ID,
+		Post:               post,
+		PublishAt:          item.PublishAt,
+		RepeatEverySeconds: item.Interval,
+		Published:          item.Published,
+	}
+}
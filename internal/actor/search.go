@@ -0,0 +1,189 @@
+package actor
+
+import (
+	"github.com/asynkron/protoactor-go/actor"
+	pb "reddit-clone/api/proto/generated"
+	"reddit-clone/internal/models"
+	"reddit-clone/internal/search"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const snippetLength = 200
+
+func (e *EngineActor) indexPostText(post *models.Post) {
+	e.search.Add(search.Document{
+		ID:          post.ID,
+		Kind:        search.KindPost,
+		SubredditID: post.SubredditID,
+		AuthorID:    post.AuthorID,
+		Created:     post.Created,
+		Title:       post.Title,
+		Body:        post.Content,
+	})
+}
+
+func (e *EngineActor) indexCommentText(comment *models.Comment, subredditID string) {
+	e.search.Add(search.Document{
+		ID:          comment.ID,
+		Kind:        search.KindComment,
+		SubredditID: subredditID,
+		AuthorID:    comment.AuthorID,
+		Created:     comment.Created,
+		Body:        comment.Content,
+	})
+}
+
+func (e *EngineActor) indexSubredditText(subreddit *models.Subreddit) {
+	e.search.Add(search.Document{
+		ID:          subreddit.ID,
+		Kind:        search.KindSubreddit,
+		SubredditID: subreddit.ID,
+		AuthorID:    subreddit.CreatorID,
+		Created:     subreddit.Created,
+		Title:       subreddit.Name,
+		Body:        subreddit.Description,
+	})
+}
+
+// rebuildSearchIndex re-indexes all content from the store after a restart
+func (e *EngineActor) rebuildSearchIndex() error {
+	subreddits, err := e.store.GetSubreddits()
+	if err != nil {
+		return err
+	}
+	for _, subreddit := range subreddits {
+		e.indexSubredditText(subreddit)
+	}
+
+	posts, err := e.store.GetPostsSince(0)
+	if err != nil {
+		return err
+	}
+	postSubreddits := make(map[string]string, len(posts))
+	for _, post := range posts {
+		e.indexPostText(post)
+		postSubreddits[post.ID] = post.SubredditID
+	}
+
+	comments, err := e.store.GetCommentsSince(0)
+	if err != nil {
+		return err
+	}
+	for _, comment := range comments {
+		e.indexCommentText(comment, postSubreddits[comment.PostID])
+	}
+	return nil
+}
+
+func (e *EngineActor) handleSearch(context actor.Context, msg *pb.SearchMessage) {
+	start := time.Now()
+
+	if strings.TrimSpace(msg.Query) == "" {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: "search query is empty"})
+		return
+	}
+
+	query := search.Query{
+		Text:        msg.Query,
+		SubredditID: msg.SubredditId,
+		AuthorID:    msg.AuthorId,
+		Since:       msg.Since,
+		Until:       msg.Until,
+	}
+	switch msg.Type {
+	case pb.SearchType_SEARCH_POSTS:
+		query.Kinds = []search.Kind{search.KindPost}
+	case pb.SearchType_SEARCH_COMMENTS:
+		query.Kinds = []search.Kind{search.KindComment}
+	case pb.SearchType_SEARCH_SUBREDDITS:
+		query.Kinds = []search.Kind{search.KindSubreddit}
+	}
+
+	// Resolve hits against the store, dropping anything no longer visible
+	results := make([]*pb.SearchResult, 0)
+	for _, hit := range e.search.Search(query) {
+		// Subreddits are listed even when private; their content is not
+		if hit.Kind != search.KindSubreddit && !e.readable(hit.SubredditID, msg.UserId) {
+			continue
+		}
+		if result := e.searchResult(hit); result != nil {
+			results = append(results, result)
+		}
+	}
+
+	switch msg.Sort {
+	case pb.SearchSort_SEARCH_NEW:
+		sort.SliceStable(results, func(i, j int) bool {
+			return results[i].CreatedAt > results[j].CreatedAt
+		})
+	case pb.SearchSort_SEARCH_TOP:
+		sort.SliceStable(results, func(i, j int) bool {
+			return results[i].Karma > results[j].Karma
+		})
+	}
+
+	page, next := paginate(results, func(result *pb.SearchResult) string { return result.Id }, msg.After, msg.Limit)
+
+	e.metrics.RecordRequest(time.Since(start).Seconds())
+	context.Respond(&pb.SearchResponse{Results: page, Next: next})
+}
+
+func (e *EngineActor) searchResult(hit search.Hit) *pb.SearchResult {
+	result := &pb.SearchResult{
+		Id:          hit.ID,
+		SubredditId: hit.SubredditID,
+		AuthorId:    hit.AuthorID,
+		CreatedAt:   hit.Created,
+		Score:       hit.Score,
+	}
+
+	switch hit.Kind {
+	case search.KindPost:
+		post, err := e.store.GetPost(hit.ID)
+		if err != nil || post.Removed || post.Filtered {
+			return nil
+		}
+		result.Type = pb.SearchType_SEARCH_POSTS
+		result.Title = post.Title
+		result.Snippet = snippet(post.Content)
+		result.Karma = post.Karma
+	case search.KindComment:
+		comment, err := e.store.GetComment(hit.ID)
+		if err != nil || comment.Removed || comment.Filtered {
+			return nil
+		}
+		result.Type = pb.SearchType_SEARCH_COMMENTS
+		result.Snippet = snippet(comment.Content)
+		result.Karma = comment.Karma
+	case search.KindSubreddit:
+		subreddit, err := e.store.GetSubreddit(hit.ID)
+		if err != nil {
+			return nil
+		}
+		result.Type = pb.SearchType_SEARCH_SUBREDDITS
+		result.Title = subreddit.Name
+		result.Snippet = snippet(subreddit.Description)
+		result.Karma = int32(len(subreddit.Members))
+	}
+	return result
+}
+
+func snippet(text string) string {
+	if len(text) <= snippetLength {
+		return text
+	}
+	cut := strings.LastIndex(text[:snippetLength], " ")
+	if cut <= 0 {
+		// No space to break at; back up so a multi-byte character is not
+		// split, since proto strings must be valid UTF-8
+		cut = snippetLength
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+	}
+	return text[:cut] + "..."
+}
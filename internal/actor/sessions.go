@@ -0,0 +1,30 @@
+package actor
+
+import (
+	"github.com/asynkron/protoactor-go/actor"
+	pb "reddit-clone/api/proto/generated"
+	"time"
+)
+
+// authenticate responds with UNAUTHENTICATED and returns false unless
+// sessionID is an unexpired session of userID. Requests without a session
+// are let through when sessions are not required.
+func (e *EngineActor) authenticate(context actor.Context, userID, sessionID string) bool {
+	if sessionID == "" && !e.config.Sessions.Required {
+		return true
+	}
+	session, err := e.store.GetSession(sessionID)
+	if err == nil && session.UserID == userID && session.Expires > time.Now().Unix() {
+		return true
+	}
+
+	e.metrics.RecordError()
+	context.Respond(&pb.ErrorResponse{Error: "a valid session is required", Code: pb.ErrorCode_UNAUTHENTICATED})
+	return false
+}
+
+// authenticateViewer is authenticate for read requests, which may also be
+// made anonymously by leaving userID empty.
+func (e *EngineActor) authenticateViewer(context actor.Context, userID, sessionID string) bool {
+	return userID == "" || e.authenticate(context, userID, sessionID)
+}
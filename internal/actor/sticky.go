@@ -0,0 +1,127 @@
+package actor
+
+import (
+	"fmt"
+	"github.com/asynkron/protoactor-go/actor"
+	pb "reddit-clone/api/proto/generated"
+	"reddit-clone/internal/models"
+	"time"
+)
+
+func (e *EngineActor) handleStickyPost(context actor.Context, msg *pb.StickyPostMessage) {
+	start := time.Now()
+
+	post, err := e.store.GetPost(msg.PostId)
+	if err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error(), Code: pb.ErrorCode_NOT_FOUND})
+		return
+	}
+	subreddit := e.requireModerator(context, post.SubredditID, msg.ModeratorId, msg.SessionId)
+	if subreddit == nil {
+		return
+	}
+
+	if msg.Sticky && !post.Sticky {
+		if post.Removed || post.Filtered {
+			e.metrics.RecordError()
+			context.Respond(&pb.ErrorResponse{Error: "removed posts cannot be made sticky"})
+			return
+		}
+		if limit := e.config.Posts.MaxStickies; len(subreddit.Stickies) >= limit {
+			e.metrics.RecordError()
+			context.Respond(&pb.ErrorResponse{Error: fmt.Sprintf("a subreddit can have at most %d sticky posts", limit)})
+			return
+		}
+	}
+
+	if err := e.store.SetPostSticky(post.ID, msg.Sticky); err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	action, result := models.ModUnsticky, "Post unstickied successfully"
+	if msg.Sticky {
+		action, result = models.ModSticky, "Post stickied successfully"
+	}
+	e.logModAction(subreddit.ID, msg.ModeratorId, action, post.ID, "")
+
+	e.metrics.RecordRequest(time.Since(start).Seconds())
+	context.Respond(&pb.SuccessResponse{Message: result})
+}
+
+func (e *EngineActor) handleLockPost(context actor.Context, msg *pb.LockPostMessage) {
+	start := time.Now()
+
+	post, err := e.store.GetPost(msg.PostId)
+	if err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error(), Code: pb.ErrorCode_NOT_FOUND})
+		return
+	}
+	if e.requireModerator(context, post.SubredditID, msg.ModeratorId, msg.SessionId) == nil {
+		return
+	}
+
+	if err := e.store.SetPostLocked(post.ID, msg.Locked); err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	action, result := models.ModUnlock, "Post unlocked successfully"
+	if msg.Locked {
+		action, result = models.ModLock, "Post locked successfully"
+	}
+	e.logModAction(post.SubredditID, msg.ModeratorId, action, post.ID, msg.Reason)
+
+	e.metrics.RecordRequest(time.Since(start).Seconds())
+	context.Respond(&pb.SuccessResponse{Message: result})
+}
+
+// checkLocked responds with LOCKED and returns false if the post is locked
+// and userID is not one of its subreddit's moderators
+func (e *EngineActor) checkLocked(context actor.Context, post *models.Post, userID string) bool {
+	if post == nil || !post.Locked {
+		return true
+	}
+	if subreddit, err := e.store.GetSubreddit(post.SubredditID); err == nil && isModerator(subreddit, userID) {
+		return true
+	}
+
+	e.metrics.RecordError()
+	context.Respond(&pb.ErrorResponse{Error: "this post is locked and cannot be commented on", Code: pb.ErrorCode_LOCKED})
+	return false
+}
+
+// pinStickies moves the subreddit's sticky posts in a listing to the front,
+// in pinned order, leaving the rest in their ranked order
+func (e *EngineActor) pinStickies(subredditID string, posts []*models.Post) []*models.Post {
+	subreddit, err := e.store.GetSubreddit(subredditID)
+	if err != nil || len(subreddit.Stickies) == 0 {
+		return posts
+	}
+
+	slots := make(map[string]int, len(subreddit.Stickies))
+	for i, id := range subreddit.Stickies {
+		slots[id] = i
+	}
+	pinned := make([]*models.Post, len(subreddit.Stickies))
+	rest := make([]*models.Post, 0, len(posts))
+	for _, post := range posts {
+		if slot, sticky := slots[post.ID]; sticky {
+			pinned[slot] = post
+		} else {
+			rest = append(rest, post)
+		}
+	}
+
+	listing := make([]*models.Post, 0, len(posts))
+	for _, post := range pinned {
+		if post != nil { // filtered out of this listing
+			listing = append(listing, post)
+		}
+	}
+	return append(listing, rest...)
+}
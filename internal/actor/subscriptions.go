@@ -0,0 +1,246 @@
+package actor
+
+import (
+	"github.com/asynkron/protoactor-go/actor"
+	pb "reddit-clone/api/proto/generated"
+	"reddit-clone/internal/models"
+	"time"
+)
+
+type topic struct {
+	Kind pb.SubscriptionTopic
+	ID   string
+}
+
+// inboxEvent is sent by a user actor so the engine can push inbox changes to subscribers
+type inboxEvent struct {
+	UserID string
+	Event  *pb.EventMessage
+}
+
+// subscriptions tracks which client actors receive which live events
+type subscriptions struct {
+	subscribers map[topic]map[string]*actor.PID // topic -> PID key -> subscriber
+	topics      map[string]map[topic]string     // PID key -> subscribed topics -> user ID
+}
+
+func newSubscriptions() *subscriptions {
+	return &subscriptions{
+		subscribers: make(map[topic]map[string]*actor.PID),
+		topics:      make(map[string]map[topic]string),
+	}
+}
+
+// add subscribes pid to t on behalf of userID, returning true if pid had no
+// subscriptions before
+func (s *subscriptions) add(pid *actor.PID, t topic, userID string) bool {
+	key := pid.String()
+	first := s.topics[key] == nil
+	if first {
+		s.topics[key] = make(map[topic]string)
+	}
+	s.topics[key][t] = userID
+
+	if s.subscribers[t] == nil {
+		s.subscribers[t] = make(map[string]*actor.PID)
+	}
+	s.subscribers[t][key] = pid
+	return first
+}
+
+// remove unsubscribes pid from t, returning true if pid has no subscriptions left
+func (s *subscriptions) remove(pid *actor.PID, t topic) bool {
+	key := pid.String()
+	delete(s.subscribers[t], key)
+	if len(s.subscribers[t]) == 0 {
+		delete(s.subscribers, t)
+	}
+
+	delete(s.topics[key], t)
+	if len(s.topics[key]) == 0 {
+		delete(s.topics, key)
+		return true
+	}
+	return false
+}
+
+func subscription(pid *actor.PID, t topic, userID string) *models.Subscription {
+	return &models.Subscription{
+		UserID:  userID,
+		Topic:   int32(t.Kind),
+		TopicID: t.ID,
+		Address: pid.Address,
+		ActorID: pid.Id,
+	}
+}
+
+// subscribe adds and stores a subscription, watching the subscriber so it is
+// dropped when the client stops
+func (e *EngineActor) subscribe(context actor.Context, pid *actor.PID, t topic, userID string) error {
+	if err := e.store.AddSubscription(subscription(pid, t, userID)); err != nil {
+		return err
+	}
+	if e.subscriptions.add(pid, t, userID) {
+		context.Watch(pid)
+	}
+	e.metrics.Subscribers.Set(float64(len(e.subscriptions.topics)))
+	return nil
+}
+
+// unsubscribe removes a subscription from memory and the store
+func (e *EngineActor) unsubscribe(context actor.Context, pid *actor.PID, t topic) {
+	userID, exists := e.subscriptions.topics[pid.String()][t]
+	if !exists {
+		return
+	}
+	if err := e.store.RemoveSubscription(subscription(pid, t, userID)); err != nil {
+		e.metrics.RecordError()
+	}
+	if e.subscriptions.remove(pid, t) {
+		context.Unwatch(pid)
+	}
+	e.metrics.Subscribers.Set(float64(len(e.subscriptions.topics)))
+}
+
+// unsubscribeAll drops every subscription held by pid
+func (e *EngineActor) unsubscribeAll(context actor.Context, pid *actor.PID) {
+	for t := range e.subscriptions.topics[pid.String()] {
+		e.unsubscribe(context, pid, t)
+	}
+}
+
+// restoreSubscriptions reloads stored subscriptions after a restart, dropping
+// any the user has since lost access to
+func (e *EngineActor) restoreSubscriptions(context actor.Context) {
+	stored, err := e.store.GetSubscriptions()
+	if err != nil {
+		e.metrics.RecordError()
+		return
+	}
+	for _, s := range stored {
+		pid := actor.NewPID(s.Address, s.ActorID)
+		t := topic{Kind: pb.SubscriptionTopic(s.Topic), ID: s.TopicID}
+		if !e.topicReadable(t, s.UserID) {
+			if err := e.store.RemoveSubscription(s); err != nil {
+				e.metrics.RecordError()
+			}
+			context.Send(pid, unsubscribedEvent(t))
+			continue
+		}
+		if e.subscriptions.add(pid, t, s.UserID) {
+			context.Watch(pid)
+		}
+	}
+	e.metrics.Subscribers.Set(float64(len(e.subscriptions.topics)))
+}
+
+// pruneSubscriptions drops subscriptions to the subreddit and its posts held
+// by users who can no longer read it, telling their clients
+func (e *EngineActor) pruneSubscriptions(context actor.Context, subredditID string) {
+	for key, topics := range e.subscriptions.topics {
+		for t, userID := range topics {
+			if t.Kind == pb.SubscriptionTopic_TOPIC_INBOX || e.topicSubreddit(t) != subredditID || e.topicReadable(t, userID) {
+				continue
+			}
+			pid := e.subscriptions.subscribers[t][key]
+			e.unsubscribe(context, pid, t)
+			context.Send(pid, unsubscribedEvent(t))
+		}
+	}
+}
+
+func unsubscribedEvent(t topic) *pb.EventMessage {
+	return &pb.EventMessage{
+		Type:    pb.EventType_EVENT_UNSUBSCRIBED,
+		Topic:   t.Kind,
+		TopicId: t.ID,
+	}
+}
+
+func (e *EngineActor) handleSubscribe(context actor.Context, msg *pb.SubscribeMessage) {
+	start := time.Now()
+
+	subscriber := context.Sender()
+	if subscriber == nil {
+		e.metrics.RecordError()
+		return
+	}
+
+	t := topic{Kind: msg.Topic, ID: msg.TargetId}
+	if msg.Unsubscribe {
+		e.unsubscribe(context, subscriber, t)
+	} else {
+		if !e.authenticate(context, msg.UserId, msg.SessionId) {
+			return
+		}
+		if !e.checkTopicAccess(context, t, msg.UserId) {
+			return
+		}
+		if err := e.subscribe(context, subscriber, t, msg.UserId); err != nil {
+			e.metrics.RecordError()
+			context.Respond(&pb.ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+
+	e.metrics.RecordRequest(time.Since(start).Seconds())
+	context.Respond(&pb.SuccessResponse{Message: "Subscription updated successfully"})
+}
+
+// topicSubreddit resolves the subreddit a subreddit or post topic belongs to
+func (e *EngineActor) topicSubreddit(t topic) string {
+	if t.Kind == pb.SubscriptionTopic_TOPIC_POST {
+		return e.targetSubreddit(t.ID)
+	}
+	return t.ID
+}
+
+// topicReadable reports whether userID may still receive events on t
+func (e *EngineActor) topicReadable(t topic, userID string) bool {
+	if t.Kind == pb.SubscriptionTopic_TOPIC_INBOX {
+		return t.ID == userID
+	}
+	subreddit, err := e.store.GetSubreddit(e.topicSubreddit(t))
+	if err != nil {
+		return true
+	}
+	ban, banned := subreddit.Bans[userID]
+	return canRead(subreddit, userID) && !(banned && ban.Active(time.Now().Unix()))
+}
+
+// checkTopicAccess keeps private subreddits and other users' inboxes from being subscribed to
+func (e *EngineActor) checkTopicAccess(context actor.Context, t topic, userID string) bool {
+	switch t.Kind {
+	case pb.SubscriptionTopic_TOPIC_SUBREDDIT, pb.SubscriptionTopic_TOPIC_POST:
+		subredditID := e.topicSubreddit(t)
+		return e.checkBanned(context, subredditID, userID) && e.checkAccess(context, subredditID, userID, false)
+	case pb.SubscriptionTopic_TOPIC_INBOX:
+		if t.ID != userID {
+			e.metrics.RecordError()
+			context.Respond(&pb.ErrorResponse{Error: "cannot subscribe to another user's inbox", Code: pb.ErrorCode_FORBIDDEN})
+			return false
+		}
+	}
+	return true
+}
+
+func (e *EngineActor) handleUnsubscribeAll(context actor.Context) {
+	if subscriber := context.Sender(); subscriber != nil {
+		e.unsubscribeAll(context, subscriber)
+	}
+}
+
+// publish pushes event to every subscriber of the topic
+func (e *EngineActor) publish(context actor.Context, kind pb.SubscriptionTopic, id string, event *pb.EventMessage) {
+	subscribers := e.subscriptions.subscribers[topic{Kind: kind, ID: id}]
+	if len(subscribers) == 0 {
+		return
+	}
+
+	event.Topic = kind
+	event.TopicId = id
+	for _, pid := range subscribers {
+		context.Send(pid, event)
+	}
+	e.metrics.RecordEventPushed(event.Type.String(), len(subscribers))
+}
@@ -0,0 +1,51 @@
+package actor
+
+import (
+	"fmt"
+	"github.com/asynkron/protoactor-go/actor"
+	"log"
+	"reddit-clone/pkg/metrics"
+	"strings"
+	"time"
+)
+
+const (
+	maxRestarts    = 10
+	restartsWithin = time.Minute
+)
+
+// supervisor restarts failed actors one-for-one, and logs and counts every
+// failure with the message that was being handled when it happened.
+type supervisor struct {
+	strategy actor.SupervisorStrategy
+	metrics  *metrics.RedditMetrics
+}
+
+func NewSupervisor(metrics *metrics.RedditMetrics) actor.SupervisorStrategy {
+	return &supervisor{
+		strategy: actor.NewOneForOneStrategy(maxRestarts, restartsWithin, actor.DefaultDecider),
+		metrics:  metrics,
+	}
+}
+
+func (s *supervisor) HandleFailure(system *actor.ActorSystem, sup actor.Supervisor, child *actor.PID, rs *actor.RestartStatistics, reason interface{}, message interface{}) {
+	kind := actorKind(child)
+	messageType := fmt.Sprintf("%T", message)
+
+	log.Printf("actor %s panicked handling %s: %v", child.Id, messageType, reason)
+	s.metrics.RecordActorFailure(kind, messageType)
+
+	s.strategy.HandleFailure(system, sup, child, rs, reason, message)
+}
+
+// actorKind maps a PID such as "engine/user-abc$3" to a low-cardinality label.
+func actorKind(pid *actor.PID) string {
+	name := pid.Id[strings.LastIndex(pid.Id, "/")+1:]
+	if strings.HasPrefix(name, "user-") {
+		return "user"
+	}
+	if i := strings.IndexAny(name, "-$"); i > 0 {
+		return name[:i]
+	}
+	return name
+}
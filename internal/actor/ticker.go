@@ -0,0 +1,34 @@
+package actor
+
+import (
+	"github.com/asynkron/protoactor-go/actor"
+	"github.com/asynkron/protoactor-go/scheduler"
+	"time"
+)
+
+// tickInterval is how often the engine runs its periodic housekeeping
+const tickInterval = 10 * time.Second
+
+// tick is sent to the engine by its own timer
+type tick struct{}
+
+func (e *EngineActor) startTicker(context actor.Context) {
+	// The timer goroutine must not use the actor's context, so send via the root context
+	timers := scheduler.NewTimerScheduler(context.ActorSystem().Root)
+	e.stopTick = timers.SendRepeatedly(tickInterval, tickInterval, context.Self(), &tick{})
+}
+
+func (e *EngineActor) stopTicker() {
+	if e.stopTick != nil {
+		e.stopTick()
+		e.stopTick = nil
+	}
+}
+
+func (e *EngineActor) handleTick(context actor.Context) {
+	now := time.Now()
+	e.publishScheduled(context, now)
+	e.publishLeaderboards(now)
+	e.publishTrending(now)
+	e.retrainForYou(context, now)
+}
@@ -0,0 +1,160 @@
+package actor
+
+import (
+	"github.com/asynkron/protoactor-go/actor"
+	pb "reddit-clone/api/proto/generated"
+	"reddit-clone/internal/trending"
+	"reddit-clone/pkg/config"
+	"strings"
+	"time"
+)
+
+// trends tracks posting, commenting and voting activity per subreddit and per post
+type trends struct {
+	subreddits *trending.Detector
+	posts      *trending.Detector
+	current    map[pb.TrendingKind]map[string]bool // what was trending at the last tick
+}
+
+func newTrends(cfg config.TrendingConfig) *trends {
+	return &trends{
+		subreddits: trending.NewDetector(cfg.Bucket, cfg.Window, cfg.SubredditBaseline),
+		posts:      trending.NewDetector(cfg.Bucket, cfg.Window, cfg.PostBaseline),
+		current:    make(map[pb.TrendingKind]map[string]bool),
+	}
+}
+
+// recordActivity counts one event in a subreddit and, if postID is set, on that post
+func (t *trends) recordActivity(subredditID, postID string, at time.Time) {
+	if subredditID != "" {
+		t.subreddits.Record(subredditID, at)
+	}
+	if postID != "" {
+		t.posts.Record(postID, at)
+	}
+}
+
+// rebuildTrends replays recent posts and comments after a restart. Votes
+// carry no timestamps so they are not replayed.
+func (e *EngineActor) rebuildTrends() error {
+	cfg := e.config.Trending
+	since := time.Now().Add(-cfg.Window - cfg.SubredditBaseline).Unix()
+
+	posts, err := e.store.GetPostsSince(since)
+	if err != nil {
+		return err
+	}
+	for _, post := range posts {
+		e.trends.recordActivity(post.SubredditID, "", time.Unix(post.Created, 0))
+	}
+
+	comments, err := e.store.GetCommentsSince(since)
+	if err != nil {
+		return err
+	}
+	for _, comment := range comments {
+		e.trends.recordActivity(e.targetSubreddit(comment.PostID), comment.PostID, time.Unix(comment.Created, 0))
+	}
+	return nil
+}
+
+func (e *EngineActor) detector(kind pb.TrendingKind) *trending.Detector {
+	if kind == pb.TrendingKind_TRENDING_POSTS {
+		return e.trends.posts
+	}
+	return e.trends.subreddits
+}
+
+func (e *EngineActor) trending(kind pb.TrendingKind, now time.Time) []trending.Trend {
+	cfg := e.config.Trending
+	return e.detector(kind).Trending(now, cfg.MinCount, cfg.MinExpected, cfg.Threshold)
+}
+
+func (e *EngineActor) handleGetTrending(context actor.Context, msg *pb.GetTrendingMessage) {
+	start := time.Now()
+
+	if msg.SubredditId != "" {
+		if msg.Kind != pb.TrendingKind_TRENDING_POSTS {
+			e.metrics.RecordError()
+			context.Respond(&pb.ErrorResponse{Error: "only trending posts can be limited to a subreddit"})
+			return
+		}
+		if !e.checkAccess(context, msg.SubredditId, msg.UserId, false) {
+			return
+		}
+	}
+
+	limit := int(msg.Limit)
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+
+	items := make([]*pb.TrendingItem, 0, limit)
+	for _, trend := range e.trending(msg.Kind, start) {
+		if len(items) == limit {
+			break
+		}
+		item := e.trendingItem(msg.Kind, trend, msg.UserId)
+		if item == nil || (msg.SubredditId != "" && item.SubredditId != msg.SubredditId) {
+			continue
+		}
+		item.Rank = int32(len(items) + 1)
+		items = append(items, item)
+	}
+
+	e.metrics.RecordRequest(time.Since(start).Seconds())
+	context.Respond(&pb.TrendingResponse{Items: items})
+}
+
+// trendingItem describes a trend, or returns nil if its subject is gone or hidden from the viewer
+func (e *EngineActor) trendingItem(kind pb.TrendingKind, trend trending.Trend, userID string) *pb.TrendingItem {
+	item := &pb.TrendingItem{
+		Id:       trend.Key,
+		Count:    trend.Count,
+		Expected: trend.Expected,
+		Score:    trend.Score,
+	}
+
+	if kind == pb.TrendingKind_TRENDING_POSTS {
+		post, err := e.store.GetPost(trend.Key)
+		if err != nil || post.Removed || post.Filtered || !e.readable(post.SubredditID, userID) {
+			return nil
+		}
+		item.Name = post.Title
+		item.SubredditId = post.SubredditID
+		item.Post = postMessage(post)
+		return item
+	}
+
+	subreddit, err := e.store.GetSubreddit(trend.Key)
+	if err != nil || !canRead(subreddit, userID) {
+		return nil
+	}
+	item.Name = subreddit.Name
+	item.SubredditId = subreddit.ID
+	return item
+}
+
+// publishTrending exports the top trends as gauges and counts anything that
+// has started trending since the last tick
+func (e *EngineActor) publishTrending(now time.Time) {
+	e.metrics.ResetTrending()
+	for _, kind := range []pb.TrendingKind{pb.TrendingKind_TRENDING_SUBREDDITS, pb.TrendingKind_TRENDING_POSTS} {
+		kindName := strings.ToLower(strings.TrimPrefix(kind.String(), "TRENDING_"))
+		previous := e.trends.current[kind]
+		current := make(map[string]bool)
+		for i, trend := range e.trending(kind, now) {
+			current[trend.Key] = true
+			if !previous[trend.Key] {
+				e.metrics.RecordTrendingSpike(kindName)
+			}
+			if i < e.config.Trending.MetricsSize {
+				e.metrics.SetTrendingScore(kindName, i+1, trend.Key, trend.Score)
+			}
+		}
+		e.trends.current[kind] = current
+	}
+}
@@ -1 +1,337 @@
 package actor
+
+import (
+	"github.com/asynkron/protoactor-go/actor"
+	pb "reddit-clone/api/proto/generated"
+	"reddit-clone/internal/models"
+	"reddit-clone/internal/store"
+	"reddit-clone/pkg/metrics"
+	"reddit-clone/pkg/utils"
+	"time"
+)
+
+const (
+	// userIdleTimeout is how long a user actor stays alive without traffic
+	// before asking the engine to passivate it.
+	userIdleTimeout = 5 * time.Minute
+	sessionTTL      = 24 * time.Hour
+)
+
+// karmaDelta is sent by the engine when one of the user's posts is voted on.
+type karmaDelta struct {
+	Delta int32
+}
+
+// subscriptionChanged keeps an active user actor in sync with joins handled by the engine.
+type subscriptionChanged struct {
+	SubredditID string
+	Joined      bool
+}
+
+// passivate is sent by an idle user actor to the engine so it can be stopped.
+type passivate struct {
+	UserID string
+}
+
+// UserActor owns the per-user state: block list, saved items, notifications, subscriptions, karma and sessions.
+// It is spawned on demand by the engine and passivated when idle.
+type UserActor struct {
+	userID        string
+	store         store.Store
+	metrics       *metrics.RedditMetrics
+	username      string
+	password      string
+	karma         int32
+	created       int64
+	blocked       map[string]bool
+	notifications []*models.Notification // oldest first
+	subscriptions map[string]bool
+	sessions      map[string]int64 // session_id -> expiry (unix seconds)
+}
+
+func NewUserActor(userID string, store store.Store, metrics *metrics.RedditMetrics) *UserActor {
+	return &UserActor{
+		userID:        userID,
+		store:         store,
+		metrics:       metrics,
+		blocked:       make(map[string]bool),
+		subscriptions: make(map[string]bool),
+		sessions:      make(map[string]int64),
+	}
+}
+
+func (u *UserActor) Receive(context actor.Context) {
+	switch msg := context.Message().(type) {
+	case *actor.Started:
+		// Also runs after a restart, so a crashed actor reloads from the store
+		u.load()
+		context.SetReceiveTimeout(userIdleTimeout)
+	case *actor.Restarting:
+		u.metrics.RecordActorRestart("user")
+	case *actor.ReceiveTimeout:
+		context.Request(context.Parent(), &passivate{UserID: u.userID})
+	case *pb.DirectMessageMessage:
+		u.handleDirectMessage(context, msg)
+	case *pb.GetMessagesMessage:
+		u.handleGetMessages(context, msg)
+	case *pb.GetConversationsMessage:
+		u.handleGetConversations(context, msg)
+	case *pb.GetConversationMessage:
+		u.handleGetConversation(context, msg)
+	case *pb.MarkConversationReadMessage:
+		u.handleMarkConversationRead(context, msg)
+	case *pb.DeleteMessageMessage:
+		u.handleDeleteMessage(context, msg)
+	case *pb.BlockUserMessage:
+		u.handleBlockUser(context, msg)
+	case *pb.SaveMessage:
+		u.handleSave(context, msg)
+	case *pb.GetUserMessage:
+		u.handleGetUser(context)
+	case *pb.LoginMessage:
+		u.handleLogin(context, msg)
+	case *pb.LogoutMessage:
+		u.handleLogout(context, msg)
+	case *pb.GetNotificationsMessage:
+		u.handleGetNotifications(context, msg)
+	case *pb.MarkNotificationsReadMessage:
+		u.handleMarkNotificationsRead(context, msg)
+	case *pb.GetUnreadCountMessage:
+		context.Respond(&pb.UnreadCountResponse{Notifications: u.unreadNotifications(), Messages: u.unreadMessages()})
+	case *notify:
+		u.handleNotify(context, msg)
+	case *karmaDelta:
+		u.handleKarmaDelta(msg)
+	case *subscriptionChanged:
+		if msg.Joined {
+			u.subscriptions[msg.SubredditID] = true
+		} else {
+			delete(u.subscriptions, msg.SubredditID)
+		}
+	}
+}
+
+// load pulls the user's state from the store when the actor is activated.
+func (u *UserActor) load() {
+	user, err := u.store.GetUser(u.userID)
+	if err != nil {
+		u.metrics.RecordError()
+		return
+	}
+	u.username = user.Username
+	u.password = user.Password
+	u.karma = user.Karma
+	u.created = user.Created
+
+	if blocked, err := u.store.GetBlockedUsers(u.userID); err == nil {
+		for _, blockedID := range blocked {
+			u.blocked[blockedID] = true
+		}
+	}
+
+	if notifications, err := u.store.GetNotifications(u.userID); err == nil {
+		u.notifications = append(u.notifications[:0], notifications...)
+	}
+
+	if subreddits, err := u.store.GetUserSubreddits(u.userID); err == nil {
+		for _, subredditID := range subreddits {
+			u.subscriptions[subredditID] = true
+		}
+	}
+
+	// Sessions outlive the actor, which is passivated long before they expire
+	if sessions, err := u.store.GetUserSessions(u.userID); err == nil {
+		for _, session := range sessions {
+			u.sessions[session.ID] = session.Expires
+		}
+	}
+}
+
+func (u *UserActor) handleDirectMessage(context actor.Context, msg *pb.DirectMessageMessage) {
+	start := time.Now()
+
+	if u.blocked[msg.GetFromId()] {
+		u.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: "recipient is not accepting messages from this user", Code: pb.ErrorCode_FORBIDDEN})
+		return
+	}
+
+	message := &models.DirectMessage{
+		ID:             msg.GetId(),
+		ConversationID: models.ConversationID(msg.GetFromId(), u.userID),
+		FromID:         msg.GetFromId(),
+		ToID:           u.userID,
+		Content:        msg.GetContent(),
+		Timestamp:      time.Now().Unix(),
+	}
+	if message.ID == "" {
+		message.ID = utils.GenerateID()
+	}
+
+	err := u.store.SendMessage(message)
+	if err != nil {
+		u.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error()})
+		return
+	}
+	context.Send(context.Parent(), &inboxEvent{UserID: u.userID, Event: &pb.EventMessage{
+		Type:    pb.EventType_EVENT_MESSAGE,
+		Message: directMessage(message),
+	}})
+
+	u.metrics.RecordRequest(time.Since(start).Seconds())
+	context.Respond(&pb.SuccessResponse{Message: "Message sent successfully"})
+}
+
+func (u *UserActor) handleGetUser(context actor.Context) {
+	response := &pb.UserResponse{
+		UserId:       u.userID,
+		Username:     u.username,
+		Karma:        u.karma,
+		CreatedAt:    u.created,
+		SubredditIds: make([]string, 0, len(u.subscriptions)),
+	}
+	for subredditID := range u.subscriptions {
+		response.SubredditIds = append(response.SubredditIds, subredditID)
+	}
+	context.Respond(response)
+}
+
+func (u *UserActor) handleLogin(context actor.Context, msg *pb.LoginMessage) {
+	if msg.Password != u.password {
+		u.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: "invalid credentials"})
+		return
+	}
+
+	now := time.Now()
+	for sessionID, expiry := range u.sessions {
+		if expiry <= now.Unix() {
+			delete(u.sessions, sessionID)
+			_ = u.store.DeleteSession(sessionID)
+		}
+	}
+
+	session := &models.Session{
+		ID:      utils.GenerateID(),
+		UserID:  u.userID,
+		Created: now.Unix(),
+		Expires: now.Add(sessionTTL).Unix(),
+	}
+	if err := u.store.CreateSession(session); err != nil {
+		u.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error()})
+		return
+	}
+	u.sessions[session.ID] = session.Expires
+
+	context.Respond(&pb.LoginResponse{SessionId: session.ID, ExpiresAt: session.Expires})
+}
+
+func (u *UserActor) handleLogout(context actor.Context, msg *pb.LogoutMessage) {
+	if _, exists := u.sessions[msg.SessionId]; !exists {
+		u.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: "session not found"})
+		return
+	}
+
+	if err := u.store.DeleteSession(msg.SessionId); err != nil {
+		u.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error()})
+		return
+	}
+	delete(u.sessions, msg.SessionId)
+	context.Respond(&pb.SuccessResponse{Message: "Logged out successfully"})
+}
+
+func (u *UserActor) handleNotify(context actor.Context, msg *notify) {
+	if err := u.store.AddNotification(msg.Notification); err != nil {
+		u.metrics.RecordError()
+		return
+	}
+	u.notifications = append(u.notifications, msg.Notification)
+	context.Send(context.Parent(), &inboxEvent{UserID: u.userID, Event: &pb.EventMessage{
+		Type:         pb.EventType_EVENT_NOTIFICATION,
+		Notification: notificationMessage(msg.Notification),
+	}})
+}
+
+func (u *UserActor) unreadNotifications() int32 {
+	var unread int32
+	for _, notification := range u.notifications {
+		if !notification.Read {
+			unread++
+		}
+	}
+	return unread
+}
+
+func (u *UserActor) handleGetNotifications(context actor.Context, msg *pb.GetNotificationsMessage) {
+	start := time.Now()
+
+	// Newest first
+	matched := make([]*models.Notification, 0, len(u.notifications))
+	for i := len(u.notifications) - 1; i >= 0; i-- {
+		if msg.UnreadOnly && u.notifications[i].Read {
+			continue
+		}
+		matched = append(matched, u.notifications[i])
+	}
+
+	page, next := paginate(matched, func(n *models.Notification) string { return n.ID }, msg.After, msg.Limit)
+
+	response := &pb.NotificationsResponse{
+		Notifications: make([]*pb.NotificationMessage, 0, len(page)),
+		Next:          next,
+		UnreadCount:   u.unreadNotifications(),
+	}
+	for _, notification := range page {
+		response.Notifications = append(response.Notifications, notificationMessage(notification))
+	}
+
+	u.metrics.RecordRequest(time.Since(start).Seconds())
+	context.Respond(response)
+}
+
+func (u *UserActor) handleMarkNotificationsRead(context actor.Context, msg *pb.MarkNotificationsReadMessage) {
+	read := !msg.Unread
+	if err := u.store.MarkNotificationsRead(u.userID, msg.NotificationIds, read); err != nil {
+		u.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	selected := make(map[string]bool, len(msg.NotificationIds))
+	for _, id := range msg.NotificationIds {
+		selected[id] = true
+	}
+	for _, notification := range u.notifications {
+		if len(selected) == 0 || selected[notification.ID] {
+			notification.Read = read
+		}
+	}
+
+	context.Respond(&pb.UnreadCountResponse{Notifications: u.unreadNotifications(), Messages: u.unreadMessages()})
+}
+
+func notificationMessage(notification *models.Notification) *pb.NotificationMessage {
+	return &pb.NotificationMessage{
+		Id:          notification.ID,
+		Type:        pb.NotificationType(notification.Type),
+		ActorId:     notification.ActorID,
+		SubredditId: notification.SubredditID,
+		PostId:      notification.PostID,
+		CommentId:   notification.CommentID,
+		Snippet:     notification.Snippet,
+		CreatedAt:   notification.Created,
+		Read:        notification.Read,
+	}
+}
+
+func (u *UserActor) handleKarmaDelta(msg *karmaDelta) {
+	if err := u.store.UpdateUserKarma(u.userID, msg.Delta); err != nil {
+		u.metrics.RecordError()
+		return
+	}
+	u.karma += msg.Delta
+}
@@ -0,0 +1,251 @@
+package actor
+
+import (
+	"github.com/asynkron/protoactor-go/actor"
+	pb "reddit-clone/api/proto/generated"
+	"reddit-clone/internal/models"
+	"sort"
+	"time"
+)
+
+// canRead reports whether userID may see the subreddit's posts and comments
+func canRead(subreddit *models.Subreddit, userID string) bool {
+	return subreddit.Visibility != models.VisibilityPrivate ||
+		subreddit.Members[userID] || isModerator(subreddit, userID)
+}
+
+// canPost reports whether userID may submit posts to the subreddit
+func canPost(subreddit *models.Subreddit, userID string) bool {
+	switch subreddit.Visibility {
+	case models.VisibilityRestricted:
+		return subreddit.Approved[userID] || isModerator(subreddit, userID)
+	case models.VisibilityPrivate:
+		return canRead(subreddit, userID)
+	}
+	return true
+}
+
+// readable reports whether userID can read subredditID; unknown subreddits are treated as public
+func (e *EngineActor) readable(subredditID, userID string) bool {
+	subreddit, err := e.store.GetSubreddit(subredditID)
+	return err != nil || canRead(subreddit, userID)
+}
+
+// checkAccess responds with FORBIDDEN and returns false if userID may not
+// read, or with posting set, submit posts to subredditID.
+func (e *EngineActor) checkAccess(context actor.Context, subredditID, userID string, posting bool) bool {
+	subreddit, err := e.store.GetSubreddit(subredditID)
+	if err != nil {
+		return true
+	}
+
+	if !canRead(subreddit, userID) {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: "this subreddit is private", Code: pb.ErrorCode_FORBIDDEN})
+		return false
+	}
+	if posting && !canPost(subreddit, userID) {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: "only approved users can post in this subreddit", Code: pb.ErrorCode_FORBIDDEN})
+		return false
+	}
+	return true
+}
+
+// addMember joins userID to the subreddit and keeps their actor and the member gauge in sync
+func (e *EngineActor) addMember(context actor.Context, subredditID, userID string) error {
+	if subreddit, err := e.store.GetSubreddit(subredditID); err == nil && subreddit.Members[userID] {
+		return nil
+	}
+	if err := e.store.JoinSubreddit(subredditID, userID); err != nil {
+		return err
+	}
+	e.notifyUser(context, userID, &subscriptionChanged{SubredditID: subredditID, Joined: true})
+	e.metrics.UpdateSubredditMembers(subredditID, 1)
+	e.leaderboards.growth.Add(subredditID, 1, time.Now())
+	e.recommender.Join(userID, subredditID)
+	return nil
+}
+
+func (e *EngineActor) handleJoinSubredditMessage(context actor.Context, msg *pb.JoinSubredditMessage) {
+	start := time.Now()
+
+	if !e.authenticate(context, msg.UserId, msg.SessionId) {
+		return
+	}
+	if !e.checkBanned(context, msg.SubredditId, msg.UserId) {
+		return
+	}
+	subreddit, err := e.store.GetSubreddit(msg.SubredditId)
+	if err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error(), Code: pb.ErrorCode_NOT_FOUND})
+		return
+	}
+
+	_, invited := subreddit.Invites[msg.UserId]
+	needsApproval := subreddit.Visibility == models.VisibilityPrivate ||
+		(subreddit.Visibility == models.VisibilityRestricted && msg.RequestApproval)
+
+	if needsApproval && !invited && !canPost(subreddit, msg.UserId) {
+		err = e.store.AddJoinRequest(msg.SubredditId, &models.JoinRequest{
+			UserID:  msg.UserId,
+			Message: msg.Message,
+			Created: time.Now().Unix(),
+		})
+		if err != nil {
+			e.metrics.RecordError()
+			context.Respond(&pb.ErrorResponse{Error: err.Error(), Code: pb.ErrorCode_DUPLICATE})
+			return
+		}
+		// Restricted subreddits can still be followed while the request is pending
+		if subreddit.Visibility == models.VisibilityRestricted {
+			err = e.addMember(context, msg.SubredditId, msg.UserId)
+		}
+		if err != nil {
+			e.metrics.RecordError()
+			context.Respond(&pb.ErrorResponse{Error: err.Error()})
+			return
+		}
+		e.metrics.RecordRequest(time.Since(start).Seconds())
+		context.Respond(&pb.SuccessResponse{Message: "Join request sent successfully"})
+		return
+	}
+
+	if invited {
+		err = e.store.ApproveUser(msg.SubredditId, msg.UserId)
+		if err == nil {
+			err = e.store.RemoveInvite(msg.SubredditId, msg.UserId)
+		}
+		// The invite supersedes any request still waiting for review
+		_ = e.store.RemoveJoinRequest(msg.SubredditId, msg.UserId)
+	}
+	if err == nil {
+		err = e.addMember(context, msg.SubredditId, msg.UserId)
+	}
+	if err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	e.metrics.RecordRequest(time.Since(start).Seconds())
+	context.Respond(&pb.SuccessResponse{Message: "Joined subreddit successfully"})
+}
+
+func (e *EngineActor) handleSetVisibility(context actor.Context, msg *pb.SetVisibilityMessage) {
+	start := time.Now()
+
+	if e.requireModerator(context, msg.SubredditId, msg.ModeratorId, msg.SessionId) == nil {
+		return
+	}
+
+	err := e.store.SetVisibility(msg.SubredditId, models.Visibility(msg.Visibility))
+	if err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	e.logModAction(msg.SubredditId, msg.ModeratorId, models.ModRuleChange, msg.SubredditId, "visibility: "+msg.Visibility.String())
+	e.pruneSubscriptions(context, msg.SubredditId)
+
+	e.metrics.RecordRequest(time.Since(start).Seconds())
+	context.Respond(&pb.SuccessResponse{Message: "Visibility updated successfully"})
+}
+
+func (e *EngineActor) handleGetJoinRequests(context actor.Context, msg *pb.GetJoinRequestsMessage) {
+	start := time.Now()
+
+	subreddit := e.requireModerator(context, msg.SubredditId, msg.ModeratorId, msg.SessionId)
+	if subreddit == nil {
+		return
+	}
+
+	// Oldest first, so requests are handled in the order they arrived
+	requests := make([]*models.JoinRequest, 0, len(subreddit.JoinRequests))
+	for _, request := range subreddit.JoinRequests {
+		requests = append(requests, request)
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		if requests[i].Created != requests[j].Created {
+			return requests[i].Created < requests[j].Created
+		}
+		return requests[i].UserID < requests[j].UserID
+	})
+
+	page, next := paginate(requests, func(r *models.JoinRequest) string { return r.UserID }, msg.After, msg.Limit)
+
+	response := &pb.JoinRequestsResponse{
+		Requests: make([]*pb.JoinRequestMessage, 0, len(page)),
+		Next:     next,
+	}
+	for _, request := range page {
+		response.Requests = append(response.Requests, &pb.JoinRequestMessage{
+			UserId:    request.UserID,
+			Message:   request.Message,
+			CreatedAt: request.Created,
+		})
+	}
+
+	e.metrics.RecordRequest(time.Since(start).Seconds())
+	context.Respond(response)
+}
+
+func (e *EngineActor) handleResolveJoinRequest(context actor.Context, msg *pb.ResolveJoinRequestMessage) {
+	start := time.Now()
+
+	if e.requireModerator(context, msg.SubredditId, msg.ModeratorId, msg.SessionId) == nil {
+		return
+	}
+
+	err := e.store.RemoveJoinRequest(msg.SubredditId, msg.UserId)
+	if err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error(), Code: pb.ErrorCode_NOT_FOUND})
+		return
+	}
+
+	action, result := models.ModRejectUser, "Join request rejected successfully"
+	if msg.Approve {
+		action, result = models.ModApproveUser, "Join request approved successfully"
+		err = e.store.ApproveUser(msg.SubredditId, msg.UserId)
+		if err == nil {
+			err = e.addMember(context, msg.SubredditId, msg.UserId)
+		}
+		if err != nil {
+			e.metrics.RecordError()
+			context.Respond(&pb.ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+	e.logModAction(msg.SubredditId, msg.ModeratorId, action, msg.UserId, "")
+
+	e.metrics.RecordRequest(time.Since(start).Seconds())
+	context.Respond(&pb.SuccessResponse{Message: result})
+}
+
+func (e *EngineActor) handleInvite(context actor.Context, msg *pb.InviteMessage) {
+	start := time.Now()
+
+	if e.requireModerator(context, msg.SubredditId, msg.ModeratorId, msg.SessionId) == nil {
+		return
+	}
+	if _, err := e.store.GetUser(msg.UserId); err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error(), Code: pb.ErrorCode_NOT_FOUND})
+		return
+	}
+
+	err := e.store.AddInvite(msg.SubredditId, msg.UserId, msg.ModeratorId)
+	if err != nil {
+		e.metrics.RecordError()
+		context.Respond(&pb.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	e.logModAction(msg.SubredditId, msg.ModeratorId, models.ModInvite, msg.UserId, "")
+
+	e.metrics.RecordRequest(time.Since(start).Seconds())
+	context.Respond(&pb.SuccessResponse{Message: "User invited successfully"})
+}
@@ -0,0 +1,256 @@
+package automod
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Config is the declarative rule set moderators upload for a subreddit, as JSON
+type Config struct {
+	Rules []RuleConfig `json:"rules"`
+}
+
+// RuleConfig is one rule as written by moderators. Every condition given
+// must hold for the rule to match; every action given is then taken.
+type RuleConfig struct {
+	Name string `json:"name"`
+	Type string `json:"type,omitempty"` // "post" or "comment"; empty matches both
+
+	Title           string   `json:"title,omitempty"`             // regular expression, posts only
+	Content         string   `json:"content,omitempty"`           // regular expression
+	Domains         []string `json:"domains,omitempty"`           // link domains, subdomains included; posts only
+	Flairs          []string `json:"flairs,omitempty"`            // post flair IDs; posts only
+	KarmaBelow      *int32   `json:"karma_below,omitempty"`       // author karma
+	AccountAgeBelow string   `json:"account_age_below,omitempty"` // Go duration such as "72h"
+	ReportsAtLeast  int      `json:"reports_at_least,omitempty"`  // checked as reports arrive
+
+	Action   string `json:"action,omitempty"` // "remove" or "filter"
+	Reason   string `json:"reason,omitempty"`
+	SetFlair string `json:"set_flair,omitempty"` // post flair template ID; posts only
+	Reply    string `json:"reply,omitempty"`
+	Lock     bool   `json:"lock,omitempty"` // posts only
+}
+
+// Action is what a rule does to the matched content itself
+type Action int
+
+const (
+	NoAction Action = iota
+	Remove
+	Filter // hide until a moderator reviews it in the mod queue
+)
+
+// Rule is a validated, compiled rule
+type Rule struct {
+	Name     string
+	Action   Action
+	Reason   string
+	SetFlair string
+	Reply    string
+	Lock     bool
+
+	posts, comments bool
+	title, content  *regexp.Regexp
+	domains         []string
+	flairs          map[string]bool
+	karmaBelow      *int32
+	accountAgeBelow time.Duration
+	reportsAtLeast  int
+}
+
+// Rules is a subreddit's compiled rule set, in the order it was written
+type Rules []*Rule
+
+// Item is the post or comment being checked
+type Item struct {
+	Post          bool // false for comments
+	Title         string
+	Content       string
+	Domain        string
+	FlairID       string
+	AuthorKarma   int32
+	AuthorCreated int64
+	Reports       int
+	// Reported is set when checking because of a new report. Only rules with
+	// a report condition are checked then, and only as the count reaches it,
+	// so each rule acts on an item once.
+	Reported bool
+	Now      time.Time
+}
+
+// Parse validates a JSON config and compiles its rules. Unknown fields are
+// rejected so that typos do not silently disable a condition.
+func Parse(text string, maxRules int) (Rules, error) {
+	decoder := json.NewDecoder(bytes.NewReader([]byte(text)))
+	decoder.DisallowUnknownFields()
+	var config Config
+	if err := decoder.Decode(&config); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	if len(config.Rules) > maxRules {
+		return nil, fmt.Errorf("a config can have at most %d rules", maxRules)
+	}
+
+	rules := make(Rules, 0, len(config.Rules))
+	names := make(map[string]bool, len(config.Rules))
+	for i, ruleConfig := range config.Rules {
+		rule, err := compile(ruleConfig)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d (%q): %w", i+1, ruleConfig.Name, err)
+		}
+		if names[rule.Name] {
+			return nil, fmt.Errorf("rule %d: duplicate name %q", i+1, rule.Name)
+		}
+		names[rule.Name] = true
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func compile(config RuleConfig) (*Rule, error) {
+	rule := &Rule{
+		Name:           strings.TrimSpace(config.Name),
+		Reason:         config.Reason,
+		SetFlair:       config.SetFlair,
+		Reply:          strings.TrimSpace(config.Reply),
+		Lock:           config.Lock,
+		domains:        make([]string, 0, len(config.Domains)),
+		karmaBelow:     config.KarmaBelow,
+		reportsAtLeast: config.ReportsAtLeast,
+	}
+	if rule.Name == "" {
+		return nil, errors.New("rules need a name")
+	}
+
+	switch config.Type {
+	case "":
+		rule.posts, rule.comments = true, true
+	case "post":
+		rule.posts = true
+	case "comment":
+		rule.comments = true
+	default:
+		return nil, fmt.Errorf("unknown type %q", config.Type)
+	}
+
+	switch config.Action {
+	case "":
+	case "remove":
+		rule.Action = Remove
+	case "filter":
+		rule.Action = Filter
+	default:
+		return nil, fmt.Errorf("unknown action %q", config.Action)
+	}
+
+	postOnly := config.Title != "" || len(config.Domains) > 0 || len(config.Flairs) > 0 ||
+		config.SetFlair != "" || config.Lock
+	if postOnly && rule.comments {
+		return nil, errors.New(`title, domains, flairs, set_flair and lock need "type": "post"`)
+	}
+
+	var err error
+	if config.Title != "" {
+		if rule.title, err = regexp.Compile(config.Title); err != nil {
+			return nil, fmt.Errorf("title: %w", err)
+		}
+	}
+	if config.Content != "" {
+		if rule.content, err = regexp.Compile(config.Content); err != nil {
+			return nil, fmt.Errorf("content: %w", err)
+		}
+	}
+	for _, domain := range config.Domains {
+		domain = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(domain)), "www.")
+		if domain == "" {
+			return nil, errors.New("domains cannot be empty")
+		}
+		rule.domains = append(rule.domains, domain)
+	}
+	if len(config.Flairs) > 0 {
+		rule.flairs = make(map[string]bool, len(config.Flairs))
+		for _, flairID := range config.Flairs {
+			rule.flairs[flairID] = true
+		}
+	}
+	if config.AccountAgeBelow != "" {
+		if rule.accountAgeBelow, err = time.ParseDuration(config.AccountAgeBelow); err != nil || rule.accountAgeBelow <= 0 {
+			return nil, fmt.Errorf("account_age_below must be a positive duration such as \"72h\"")
+		}
+	}
+	if config.ReportsAtLeast < 0 {
+		return nil, errors.New("reports_at_least cannot be negative")
+	}
+
+	conditions := rule.title != nil || rule.content != nil || len(rule.domains) > 0 || rule.flairs != nil ||
+		rule.karmaBelow != nil || rule.accountAgeBelow > 0 || rule.reportsAtLeast > 0
+	if !conditions {
+		return nil, errors.New("rules need at least one condition")
+	}
+	if rule.Action == NoAction && rule.SetFlair == "" && rule.Reply == "" && !rule.Lock {
+		return nil, errors.New("rules need at least one action")
+	}
+	return rule, nil
+}
+
+// Evaluate returns the rules that match the item, in config order
+func (r Rules) Evaluate(item Item) []*Rule {
+	var matched []*Rule
+	for _, rule := range r {
+		if rule.matches(item) {
+			matched = append(matched, rule)
+		}
+	}
+	return matched
+}
+
+func (r *Rule) matches(item Item) bool {
+	if (item.Post && !r.posts) || (!item.Post && !r.comments) {
+		return false
+	}
+	if item.Reported {
+		if r.reportsAtLeast == 0 || item.Reports != r.reportsAtLeast {
+			return false
+		}
+	} else if item.Reports < r.reportsAtLeast {
+		return false
+	}
+
+	if r.title != nil && !r.title.MatchString(item.Title) {
+		return false
+	}
+	if r.content != nil && !r.content.MatchString(item.Content) {
+		return false
+	}
+	if len(r.domains) > 0 && !matchesDomain(item.Domain, r.domains) {
+		return false
+	}
+	if r.flairs != nil && !r.flairs[item.FlairID] {
+		return false
+	}
+	if r.karmaBelow != nil && item.AuthorKarma >= *r.karmaBelow {
+		return false
+	}
+	if r.accountAgeBelow > 0 && item.Now.Sub(time.Unix(item.AuthorCreated, 0)) >= r.accountAgeBelow {
+		return false
+	}
+	return true
+}
+
+func matchesDomain(domain string, domains []string) bool {
+	domain = strings.ToLower(domain)
+	if domain == "" {
+		return false
+	}
+	for _, candidate := range domains {
+		if domain == candidate || strings.HasSuffix(domain, "."+candidate) {
+			return true
+		}
+	}
+	return false
+}
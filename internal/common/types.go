@@ -124,10 +124,12 @@ func generateActiveHours() []int {
 type ActionType string
 
 const (
-	PostAction    ActionType = "post"
-	CommentAction ActionType = "comment"
-	VoteAction    ActionType = "vote"
-	JoinAction    ActionType = "join"
+	PostAction      ActionType = "post"
+	CommentAction   ActionType = "comment"
+	VoteAction      ActionType = "vote"
+	JoinAction      ActionType = "join"
+	MessageAction   ActionType = "message"
+	SubredditAction ActionType = "subreddit"
 )
 
 type Action struct {
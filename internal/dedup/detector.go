@@ -0,0 +1,157 @@
+package dedup
+
+import "time"
+
+// bands splits a SimHash into 16-bit blocks. Two fingerprints within
+// bands-1 bits of each other must share at least one block exactly, so
+// candidates are found by block lookup rather than a full scan.
+const bands = 4
+
+type entry struct {
+	postID      string
+	subredditID string
+	fingerprint Fingerprint
+	created     int64
+}
+
+// Match describes an earlier post that a new post duplicates
+type Match struct {
+	PostID      string
+	SubredditID string
+	Exact       bool
+	Distance    int
+}
+
+// Detector indexes recent post fingerprints for duplicate lookups.
+// It is owned by the engine actor and is not safe for concurrent use.
+type Detector struct {
+	window      time.Duration
+	maxDistance int
+	entries     []*entry // ordered by creation time
+	exact       map[uint64][]*entry
+	bands       [bands]map[uint16][]*entry
+}
+
+// NewDetector creates a detector that remembers posts for window and treats
+// SimHashes at most maxDistance bits apart as near-duplicates.
+func NewDetector(window time.Duration, maxDistance int) *Detector {
+	if maxDistance >= bands {
+		maxDistance = bands - 1
+	}
+	d := &Detector{
+		window:      window,
+		maxDistance: maxDistance,
+		exact:       make(map[uint64][]*entry),
+	}
+	for i := range d.bands {
+		d.bands[i] = make(map[uint16][]*entry)
+	}
+	return d
+}
+
+// Check returns the closest earlier duplicate inside subredditID and the
+// closest one in any other subreddit; either may be nil.
+func (d *Detector) Check(subredditID string, fingerprint Fingerprint, now time.Time) (local, other *Match) {
+	d.evict(now)
+
+	consider := func(e *entry) {
+		match := &Match{
+			PostID:      e.postID,
+			SubredditID: e.subredditID,
+			Exact:       e.fingerprint.Hash == fingerprint.Hash,
+			Distance:    e.fingerprint.Distance(fingerprint),
+		}
+		if !match.Exact && match.Distance > d.maxDistance {
+			return
+		}
+		best := &other
+		if e.subredditID == subredditID {
+			best = &local
+		}
+		if *best == nil || closer(match, *best) {
+			*best = match
+		}
+	}
+
+	for _, e := range d.exact[fingerprint.Hash] {
+		consider(e)
+	}
+	for i := range d.bands {
+		for _, e := range d.bands[i][band(fingerprint.SimHash, i)] {
+			consider(e)
+		}
+	}
+	return local, other
+}
+
+// Add indexes a post so later posts can be checked against it
+func (d *Detector) Add(postID, subredditID string, fingerprint Fingerprint, created int64) {
+	e := &entry{
+		postID:      postID,
+		subredditID: subredditID,
+		fingerprint: fingerprint,
+		created:     created,
+	}
+
+	// Keep entries ordered; posts rebuilt from the store may arrive out of order
+	i := len(d.entries)
+	for i > 0 && d.entries[i-1].created > created {
+		i--
+	}
+	d.entries = append(d.entries, nil)
+	copy(d.entries[i+1:], d.entries[i:])
+	d.entries[i] = e
+
+	d.exact[fingerprint.Hash] = append(d.exact[fingerprint.Hash], e)
+	for i := range d.bands {
+		key := band(fingerprint.SimHash, i)
+		d.bands[i][key] = append(d.bands[i][key], e)
+	}
+}
+
+// Len is the number of posts still inside the window as of the last Check
+func (d *Detector) Len() int {
+	return len(d.entries)
+}
+
+// evict drops entries that have fallen out of the window
+func (d *Detector) evict(now time.Time) {
+	cutoff := now.Add(-d.window).Unix()
+	n := 0
+	for n < len(d.entries) && d.entries[n].created < cutoff {
+		e := d.entries[n]
+		d.exact[e.fingerprint.Hash] = remove(d.exact[e.fingerprint.Hash], e)
+		if len(d.exact[e.fingerprint.Hash]) == 0 {
+			delete(d.exact, e.fingerprint.Hash)
+		}
+		for i := range d.bands {
+			key := band(e.fingerprint.SimHash, i)
+			d.bands[i][key] = remove(d.bands[i][key], e)
+			if len(d.bands[i][key]) == 0 {
+				delete(d.bands[i], key)
+			}
+		}
+		n++
+	}
+	d.entries = d.entries[n:]
+}
+
+func closer(a, b *Match) bool {
+	if a.Exact != b.Exact {
+		return a.Exact
+	}
+	return a.Distance < b.Distance
+}
+
+func band(simHash uint64, i int) uint16 {
+	return uint16(simHash >> (16 * uint(i)))
+}
+
+func remove(entries []*entry, target *entry) []*entry {
+	for i, e := range entries {
+		if e == target {
+			return append(entries[:i], entries[i+1:]...)
+		}
+	}
+	return entries
+}
@@ -0,0 +1,71 @@
+package dedup
+
+import (
+	"hash/fnv"
+	"math/bits"
+	"strings"
+	"unicode"
+)
+
+// Fingerprint identifies a post's text. Hash matches only on identical
+// normalised text; SimHash is close in Hamming distance for similar text.
+type Fingerprint struct {
+	Hash    uint64
+	SimHash uint64
+}
+
+// NewFingerprint fingerprints a post from its title and content
+func NewFingerprint(title, content string) Fingerprint {
+	tokens := tokenize(title + " " + content)
+	return Fingerprint{
+		Hash:    hash64(strings.Join(tokens, " ")),
+		SimHash: simHash(tokens),
+	}
+}
+
+// Distance is the number of differing SimHash bits
+func (f Fingerprint) Distance(other Fingerprint) int {
+	return bits.OnesCount64(f.SimHash ^ other.SimHash)
+}
+
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// simHash builds a 64-bit SimHash over single words and word bigrams
+func simHash(tokens []string) uint64 {
+	var weights [64]int
+	add := func(feature string) {
+		h := hash64(feature)
+		for i := 0; i < 64; i++ {
+			if h&(1<<uint(i)) != 0 {
+				weights[i]++
+			} else {
+				weights[i]--
+			}
+		}
+	}
+
+	for i, token := range tokens {
+		add(token)
+		if i > 0 {
+			add(tokens[i-1] + " " + token)
+		}
+	}
+
+	var fingerprint uint64
+	for i, weight := range weights {
+		if weight > 0 {
+			fingerprint |= 1 << uint(i)
+		}
+	}
+	return fingerprint
+}
+
+func hash64(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return h.Sum64()
+}
@@ -0,0 +1,186 @@
+package leaderboard
+
+import (
+	"container/heap"
+	"time"
+)
+
+// Window is the period a ranking covers
+type Window int
+
+const (
+	AllTime Window = iota
+	Day
+	Week
+)
+
+// bucketWidth is the granularity of the sliding windows; scores drop out of
+// a window up to one bucket late.
+const bucketWidth = time.Hour
+
+var windowBuckets = map[Window]int64{
+	Day:  int64(24 * time.Hour / bucketWidth),
+	Week: int64(7 * 24 * time.Hour / bucketWidth),
+}
+
+// Entry is a ranked key and its score
+type Entry struct {
+	ID    string
+	Score int64
+}
+
+// Board ranks keys by scores accumulated from events, all-time and over
+// sliding day and week windows. Scores are updated as events arrive, so
+// ranking never rescans the events themselves.
+// It is owned by the engine actor and is not safe for concurrent use.
+type Board struct {
+	allTime map[string]int64
+	windows map[Window]*sliding
+}
+
+func NewBoard() *Board {
+	board := &Board{
+		allTime: make(map[string]int64),
+		windows: make(map[Window]*sliding, len(windowBuckets)),
+	}
+	for window, buckets := range windowBuckets {
+		board.windows[window] = newSliding(buckets)
+	}
+	return board
+}
+
+// Add adds delta to key's score for an event that happened at at
+func (b *Board) Add(key string, delta int64, at time.Time) {
+	if delta == 0 {
+		return
+	}
+	addScore(b.allTime, key, delta)
+	for _, window := range b.windows {
+		window.add(key, delta, at)
+	}
+}
+
+// Score returns key's current score in window
+func (b *Board) Score(window Window, key string, now time.Time) int64 {
+	return b.scores(window, now)[key]
+}
+
+// Top returns the n highest-scoring keys in window, best first. Ties are
+// broken by key so rankings are stable.
+func (b *Board) Top(window Window, n int, now time.Time) []Entry {
+	if n <= 0 {
+		return nil
+	}
+
+	// Keep the best n seen so far in a min-heap
+	top := make(entryHeap, 0, n)
+	for id, score := range b.scores(window, now) {
+		entry := Entry{ID: id, Score: score}
+		if len(top) < n {
+			heap.Push(&top, entry)
+		} else if better(entry, top[0]) {
+			top[0] = entry
+			heap.Fix(&top, 0)
+		}
+	}
+
+	ranked := make([]Entry, len(top))
+	for i := len(ranked) - 1; i >= 0; i-- {
+		ranked[i] = heap.Pop(&top).(Entry)
+	}
+	return ranked
+}
+
+func (b *Board) scores(window Window, now time.Time) map[string]int64 {
+	sliding, exists := b.windows[window]
+	if !exists {
+		return b.allTime
+	}
+	sliding.advance(now)
+	return sliding.totals
+}
+
+func addScore(scores map[string]int64, key string, delta int64) {
+	scores[key] += delta
+	if scores[key] == 0 {
+		delete(scores, key)
+	}
+}
+
+// sliding keeps per-bucket deltas and a running total over the last size buckets
+type sliding struct {
+	size    int64
+	newest  int64 // index of the newest bucket seen
+	buckets map[int64]map[string]int64
+	totals  map[string]int64
+}
+
+func newSliding(size int64) *sliding {
+	return &sliding{
+		size:    size,
+		buckets: make(map[int64]map[string]int64),
+		totals:  make(map[string]int64),
+	}
+}
+
+func bucketIndex(t time.Time) int64 {
+	return t.Unix() / int64(bucketWidth/time.Second)
+}
+
+func (s *sliding) add(key string, delta int64, at time.Time) {
+	index := bucketIndex(at)
+	s.advanceTo(index)
+	if index <= s.newest-s.size {
+		return // already outside the window
+	}
+
+	bucket, exists := s.buckets[index]
+	if !exists {
+		bucket = make(map[string]int64)
+		s.buckets[index] = bucket
+	}
+	bucket[key] += delta
+	addScore(s.totals, key, delta)
+}
+
+func (s *sliding) advance(now time.Time) {
+	s.advanceTo(bucketIndex(now))
+}
+
+// advanceTo expires buckets that fall out of the window ending at index
+func (s *sliding) advanceTo(index int64) {
+	if index <= s.newest {
+		return
+	}
+	s.newest = index
+	for bucketIndex, bucket := range s.buckets {
+		if bucketIndex > s.newest-s.size {
+			continue
+		}
+		for key, delta := range bucket {
+			addScore(s.totals, key, -delta)
+		}
+		delete(s.buckets, bucketIndex)
+	}
+}
+
+func better(a, b Entry) bool {
+	if a.Score != b.Score {
+		return a.Score > b.Score
+	}
+	return a.ID < b.ID
+}
+
+// entryHeap is a min-heap with the worst entry at the root
+type entryHeap []Entry
+
+func (h entryHeap) Len() int            { return len(h) }
+func (h entryHeap) Less(i, j int) bool  { return better(h[j], h[i]) }
+func (h entryHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *entryHeap) Push(x interface{}) { *h = append(*h, x.(Entry)) }
+func (h *entryHeap) Pop() interface{} {
+	old := *h
+	entry := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return entry
+}
@@ -0,0 +1,10 @@
+package models
+
+// Blob is an uploaded file, such as an image attached to a post
+type Blob struct {
+	ID          string
+	OwnerID     string
+	ContentType string
+	Data        []byte
+	Created     int64
+}
@@ -6,6 +6,10 @@ type Comment struct {
 	ParentID string // empty if top-level comment
 	AuthorID string
 	Content  string
+	Karma    int32
 	Created  int64
 	Children []string // IDs of child comments
+	Removed  bool
+	Approved bool
+	Filtered bool // hidden until a moderator reviews it
 }
@@ -0,0 +1,9 @@
+package models
+
+// Flair is a label a subreddit defines for posts or users
+type Flair struct {
+	ID      string
+	Text    string
+	Color   string // "#rrggbb"
+	ModOnly bool   // only moderators may assign it
+}
@@ -1,9 +1,40 @@
 package models
 
 type DirectMessage struct {
-	ID        string
-	FromID    string
-	ToID      string
-	Content   string
-	Timestamp int64
+	ID                 string
+	ConversationID     string
+	FromID             string
+	ToID               string
+	Content            string
+	Timestamp          int64
+	ReadAt             int64 // 0 until the recipient reads it
+	DeletedBySender    bool
+	DeletedByRecipient bool
+}
+
+// ConversationID identifies the two-party thread between a and b regardless of order
+func ConversationID(a, b string) string {
+	if a > b {
+		a, b = b, a
+	}
+	return a + ":" + b
+}
+
+// VisibleTo reports whether userID can still see the message
+func (m *DirectMessage) VisibleTo(userID string) bool {
+	switch userID {
+	case m.FromID:
+		return !m.DeletedBySender
+	case m.ToID:
+		return !m.DeletedByRecipient
+	}
+	return false
+}
+
+// OtherParty returns the participant who is not userID
+func (m *DirectMessage) OtherParty(userID string) string {
+	if m.FromID == userID {
+		return m.ToID
+	}
+	return m.FromID
 }
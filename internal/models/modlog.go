@@ -0,0 +1,35 @@
+package models
+
+type ModAction string
+
+const (
+	ModRemove          ModAction = "remove"
+	ModApprove         ModAction = "approve"
+	ModBan             ModAction = "ban"
+	ModUnban           ModAction = "unban"
+	ModAddModerator    ModAction = "add_moderator"
+	ModRemoveModerator ModAction = "remove_moderator"
+	ModDismissReports  ModAction = "dismiss_reports"
+	ModRuleChange      ModAction = "rule_change"
+	ModFlair           ModAction = "flair"
+	ModApproveUser     ModAction = "approve_user"
+	ModRejectUser      ModAction = "reject_user"
+	ModInvite          ModAction = "invite"
+	ModSticky          ModAction = "sticky"
+	ModUnsticky        ModAction = "unsticky"
+	ModLock            ModAction = "lock"
+	ModUnlock          ModAction = "unlock"
+	ModFilter          ModAction = "filter"
+	ModReply           ModAction = "reply"
+)
+
+// ModLogEntry records a single moderator action. Entries are never modified.
+type ModLogEntry struct {
+	ID          string
+	SubredditID string
+	ModeratorID string
+	Action      ModAction
+	TargetID    string
+	Reason      string
+	Created     int64
+}
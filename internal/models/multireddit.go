@@ -0,0 +1,14 @@
+package models
+
+// Multireddit is a user's named custom feed combining several subreddits.
+// Public multireddits can be viewed and copied by anyone with the ID.
+type Multireddit struct {
+	ID           string
+	OwnerID      string
+	Name         string
+	Description  string
+	SubredditIDs []string
+	Public       bool
+	Created      int64
+	Updated      int64
+}
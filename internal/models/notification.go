@@ -0,0 +1,24 @@
+package models
+
+type NotificationType int
+
+const (
+	NotifyCommentReply NotificationType = iota
+	NotifyPostReply
+	NotifyMention
+	NotifyScheduledPostPublished
+	NotifyScheduledPostFailed
+)
+
+type Notification struct {
+	ID          string
+	UserID      string // recipient
+	Type        NotificationType
+	ActorID     string // user whose content triggered the notification
+	SubredditID string
+	PostID      string
+	CommentID   string // empty for mentions in a post
+	Snippet     string
+	Created     int64
+	Read        bool
+}
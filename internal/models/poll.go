@@ -0,0 +1,17 @@
+package models
+
+type PollOption struct {
+	Text  string
+	Votes int32
+}
+
+type Poll struct {
+	Options []PollOption
+	Closes  int64
+	Voters  map[string]int // user_id -> chosen option index
+}
+
+// Open reports whether the poll still accepts votes at now (unix seconds)
+func (p *Poll) Open(now int64) bool {
+	return now < p.Closes
+}
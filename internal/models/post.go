@@ -1,5 +1,14 @@
 package models
 
+type PostType int
+
+const (
+	PostText PostType = iota
+	PostLink
+	PostPoll
+	PostImage
+)
+
 type Post struct {
 	ID          string
 	SubredditID string
@@ -9,4 +18,21 @@ type Post struct {
 	Karma       int32
 	Created     int64
 	Votes       map[string]bool // user_id -> upvote(true)/downvote(false)
+	IsRepost    bool
+	DuplicateOf string // ID of the earlier post this one duplicates
+	ContentHash uint64
+	SimHash     uint64
+	Removed     bool
+	Approved    bool
+	Filtered    bool   // hidden until a moderator reviews it
+	CrosspostOf string // ID of the original post this one was crossposted from
+	Crossposts  int32  // number of times this post has been crossposted
+	Type        PostType
+	URL         string   // normalized link target, for link posts
+	Domain      string   // link host without "www."
+	Poll        *Poll    // for poll posts
+	ImageIDs    []string // uploaded blob IDs, for image posts
+	Flair       *Flair   // copied from the subreddit's templates when assigned
+	Sticky      bool     // pinned to the top of the subreddit
+	Locked      bool     // closed to new comments
 }
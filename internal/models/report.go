@@ -0,0 +1,26 @@
+package models
+
+type TargetType int
+
+const (
+	TargetPost TargetType = iota
+	TargetComment
+)
+
+// QueueItem is a post or comment awaiting moderator review, either because
+// users reported it or because it was filtered automatically.
+type QueueItem struct {
+	TargetID     string
+	TargetType   TargetType
+	SubredditID  string
+	Reasons      map[string]int  // reason -> number of reports
+	Reporters    map[string]bool // user_id -> reported
+	Filtered     bool
+	FilterReason string
+	Created      int64
+}
+
+// ReportCount is the number of distinct users who reported the item
+func (q *QueueItem) ReportCount() int {
+	return len(q.Reporters)
+}
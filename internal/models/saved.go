@@ -0,0 +1,10 @@
+package models
+
+// SavedItem is a post or comment bookmarked by a user
+type SavedItem struct {
+	UserID      string
+	TargetID    string
+	TargetType  TargetType
+	SubredditID string
+	Saved       int64
+}
@@ -0,0 +1,13 @@
+package models
+
+// ScheduledPost is a post held back until PublishAt. Post is the template
+// each published post is copied from; recurring posts are published again
+// every Interval seconds.
+type ScheduledPost struct {
+	ID        string
+	Post      *Post
+	PublishAt int64
+	Interval  int64 // 0 for a one-off post
+	Published int32 // posts published from this schedule so far
+	Created   int64
+}
@@ -0,0 +1,9 @@
+package models
+
+// Session is a login issued to a user, valid until Expires
+type Session struct {
+	ID      string
+	UserID  string
+	Created int64
+	Expires int64
+}
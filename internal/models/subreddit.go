@@ -1,10 +1,70 @@
 package models
 
+// RepostPolicy controls what happens to duplicate posts in a subreddit
+type RepostPolicy int
+
+const (
+	RepostFlag        RepostPolicy = iota // accept and flag duplicates
+	RepostRejectLocal                     // reject duplicates of posts in the same subreddit
+	RepostRejectAny                       // reject duplicates of posts in any subreddit
+)
+
+// Visibility controls who can read and contribute to a subreddit
+type Visibility int
+
+const (
+	VisibilityPublic     Visibility = iota // anyone reads and contributes
+	VisibilityRestricted                   // anyone reads, approved users contribute
+	VisibilityPrivate                      // only members read and contribute
+)
+
 type Subreddit struct {
-	ID          string
-	Name        string
-	Description string
-	CreatorID   string
-	Members     map[string]bool // user_id -> membership status
+	ID            string
+	Name          string
+	Description   string
+	CreatorID     string
+	Members       map[string]bool // user_id -> membership status
+	Created       int64
+	RepostPolicy  RepostPolicy
+	Moderators    []string        // user IDs, creator first
+	Bans          map[string]*Ban // user_id -> ban
+	ReportReasons []string
+	PostFlairs    []*Flair
+	UserFlairs    []*Flair
+	UserFlair     map[string]string // user_id -> user flair ID
+	Visibility    Visibility
+	Approved      map[string]bool         // user_id -> approved contributor, for restricted subreddits
+	JoinRequests  map[string]*JoinRequest // user_id -> pending request
+	Invites       map[string]string       // user_id -> inviting moderator ID
+	Stickies      []string                // pinned post IDs, in display order
+	AutoModConfig string                  // AutoModerator rules as JSON, validated when set
+}
+
+type JoinRequest struct {
+	UserID  string
+	Message string
+	Created int64
+}
+
+// FindFlair returns the template with the given ID, or nil
+func FindFlair(flairs []*Flair, id string) *Flair {
+	for _, flair := range flairs {
+		if flair.ID == id {
+			return flair
+		}
+	}
+	return nil
+}
+
+type Ban struct {
+	UserID      string
+	ModeratorID string
+	Reason      string
 	Created     int64
+	Expires     int64 // 0 if permanent
+}
+
+// Active reports whether the ban is still in effect at now (unix seconds)
+func (b *Ban) Active(now int64) bool {
+	return b.Expires == 0 || b.Expires > now
 }
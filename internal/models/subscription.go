@@ -0,0 +1,19 @@
+package models
+
+import "fmt"
+
+// Subscription is a client actor's request for live events on a topic. It is
+// stored so subscriptions outlive an engine restart.
+type Subscription struct {
+	UserID  string
+	Topic   int32 // a SubscriptionTopic
+	TopicID string
+	// Address and ActorID identify the subscriber's PID
+	Address string
+	ActorID string
+}
+
+// Key identifies the subscription; a subscriber holds each topic once
+func (s *Subscription) Key() string {
+	return fmt.Sprintf("%s/%s|%d|%s", s.Address, s.ActorID, s.Topic, s.TopicID)
+}
@@ -0,0 +1,112 @@
+package ratelimit
+
+import (
+	"math"
+	"reddit-clone/pkg/config"
+	"time"
+)
+
+// pruneEvery is how many calls to Allow pass between sweeps of idle buckets
+const pruneEvery = 10000
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	limit  config.Limit
+}
+
+// Limiter is a set of token buckets keyed by an arbitrary string.
+// It is owned by a single actor and is not safe for concurrent use.
+type Limiter struct {
+	buckets map[string]*bucket
+	calls   int
+	now     func() time.Time
+}
+
+func NewLimiter() *Limiter {
+	return NewLimiterWithClock(time.Now)
+}
+
+// NewLimiterWithClock is NewLimiter reading the time from now rather than the wall clock
+func NewLimiterWithClock(now func() time.Time) *Limiter {
+	return &Limiter{
+		buckets: make(map[string]*bucket),
+		now:     now,
+	}
+}
+
+// Len is the number of buckets being tracked
+func (l *Limiter) Len() int {
+	return len(l.buckets)
+}
+
+// Request names the bucket to take a token from and the limit it is held to
+type Request struct {
+	Key   string
+	Limit config.Limit
+}
+
+// Allow takes a token from the bucket for key. If none is available it
+// returns false and how long until the next token is added.
+func (l *Limiter) Allow(key string, limit config.Limit) (bool, time.Duration) {
+	return l.AllowAll(Request{Key: key, Limit: limit})
+}
+
+// AllowAll takes a token from every requested bucket, or from none of them
+// if any is empty. In that case it returns false and how long until all of
+// them have a token again.
+func (l *Limiter) AllowAll(requests ...Request) (bool, time.Duration) {
+	now := l.now()
+
+	l.calls++
+	if l.calls%pruneEvery == 0 {
+		l.prune(now)
+	}
+
+	buckets := make([]*bucket, len(requests))
+	var wait time.Duration
+	for i, request := range requests {
+		b, exists := l.buckets[request.Key]
+		if !exists {
+			b = &bucket{tokens: float64(request.Limit.Burst), last: now}
+			l.buckets[request.Key] = b
+		}
+		b.limit = request.Limit
+		b.refill(now)
+		buckets[i] = b
+
+		if b.tokens < 1 {
+			if request.Limit.Rate <= 0 {
+				return false, time.Duration(math.MaxInt64)
+			}
+			if w := time.Duration((1 - b.tokens) / request.Limit.Rate * float64(time.Second)); w > wait {
+				wait = w
+			}
+		}
+	}
+	if wait > 0 {
+		return false, wait
+	}
+
+	for _, b := range buckets {
+		b.tokens--
+	}
+	return true, 0
+}
+
+func (b *bucket) refill(now time.Time) {
+	elapsed := now.Sub(b.last).Seconds()
+	b.tokens = math.Min(float64(b.limit.Burst), b.tokens+elapsed*b.limit.Rate)
+	b.last = now
+}
+
+// prune drops buckets that have refilled completely, as they are
+// indistinguishable from a fresh bucket.
+func (l *Limiter) prune(now time.Time) {
+	for key, b := range l.buckets {
+		b.refill(now)
+		if b.tokens >= float64(b.limit.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
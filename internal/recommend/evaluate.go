@@ -0,0 +1,101 @@
+package recommend
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Dataset is a snapshot of memberships and votes to evaluate against
+type Dataset struct {
+	Joins map[string][]string       // user -> subreddits joined
+	Votes map[string]map[string]int // user -> subreddit -> net upvotes
+}
+
+// Result is how well recommendations predicted held-out joins
+type Result struct {
+	Users     int // users with held-out joins
+	HeldOut   int
+	Hits      int     // held-out joins that were recommended
+	Precision float64 // hits per recommendation made
+	Recall    float64 // hits per held-out join
+}
+
+// Evaluate hides a fraction of each user's joins, trains on the rest and
+// measures how many hidden joins appear in each user's top k
+// recommendations. Users with fewer than two joins keep them all. Votes in
+// hidden subreddits are dropped too so they cannot leak the answer. The
+// popularity baseline is scored on the same split.
+func Evaluate(data Dataset, holdout float64, k int, voteWeight float64, rng *rand.Rand) (model, popular Result) {
+	recommender := NewRecommender(voteWeight)
+	hidden := make(map[string]map[string]bool)
+
+	// Visit users and joins in a fixed order so a seeded rng gives repeatable splits
+	users := make([]string, 0, len(data.Joins))
+	for userID := range data.Joins {
+		users = append(users, userID)
+	}
+	sort.Strings(users)
+
+	for _, userID := range users {
+		joins := append([]string(nil), data.Joins[userID]...)
+		sort.Strings(joins)
+		rng.Shuffle(len(joins), func(i, j int) { joins[i], joins[j] = joins[j], joins[i] })
+
+		held := 0
+		if len(joins) >= 2 {
+			held = int(math.Round(holdout * float64(len(joins))))
+			held = min(max(held, 1), len(joins)-1)
+		}
+		if held > 0 {
+			hidden[userID] = make(map[string]bool, held)
+			for _, subredditID := range joins[:held] {
+				hidden[userID][subredditID] = true
+			}
+		}
+		for _, subredditID := range joins[held:] {
+			recommender.Join(userID, subredditID)
+		}
+	}
+
+	for userID, votes := range data.Votes {
+		for subredditID, net := range votes {
+			if hidden[userID][subredditID] {
+				continue
+			}
+			recommender.Vote(userID, subredditID, net)
+		}
+	}
+
+	for _, userID := range users {
+		if len(hidden[userID]) == 0 {
+			continue
+		}
+		model.add(recommender.Recommend(userID, k, nil), hidden[userID])
+		popular.add(recommender.Popular(userID, k, nil), hidden[userID])
+	}
+	model.finish(k)
+	popular.finish(k)
+	return model, popular
+}
+
+func (r *Result) add(recommendations []Recommendation, hidden map[string]bool) {
+	r.Users++
+	r.HeldOut += len(hidden)
+	for _, recommendation := range recommendations {
+		if hidden[recommendation.SubredditID] {
+			r.Hits++
+		}
+	}
+}
+
+// finish computes the rates; precision counts k recommendations per user even
+// when fewer were available, so sparse models are not flattered
+func (r *Result) finish(k int) {
+	if r.Users > 0 && k > 0 {
+		r.Precision = float64(r.Hits) / float64(r.Users*k)
+	}
+	if r.HeldOut > 0 {
+		r.Recall = float64(r.Hits) / float64(r.HeldOut)
+	}
+}
@@ -0,0 +1,188 @@
+package recommend
+
+import (
+	"math"
+	"sort"
+)
+
+// PostRecommendation is a suggested post and how strongly it is suggested
+type PostRecommendation struct {
+	PostID string
+	Score  float64
+}
+
+// Interactions accumulates weighted user-post signals such as votes,
+// comments and saves. A zero or negative weight still marks the post as seen.
+// It is owned by the engine actor and is not safe for concurrent use.
+type Interactions struct {
+	users   map[string]map[string]float64 // user -> post -> weight
+	created map[string]int64              // post -> creation time
+}
+
+func NewInteractions() *Interactions {
+	return &Interactions{
+		users:   make(map[string]map[string]float64),
+		created: make(map[string]int64),
+	}
+}
+
+// Add adds weight to userID's signal for a post created at created
+func (s *Interactions) Add(userID, postID string, created int64, weight float64) {
+	posts, exists := s.users[userID]
+	if !exists {
+		posts = make(map[string]float64)
+		s.users[userID] = posts
+	}
+	posts[postID] += weight
+	s.created[postID] = created
+}
+
+// Of returns userID's signals; the map must not be modified
+func (s *Interactions) Of(userID string) map[string]float64 {
+	return s.users[userID]
+}
+
+// Counts returns how many users and posts have signals
+func (s *Interactions) Counts() (users, posts int) {
+	return len(s.users), len(s.created)
+}
+
+// Prune forgets posts created before the given time
+func (s *Interactions) Prune(before int64) {
+	for userID, posts := range s.users {
+		for postID := range posts {
+			if s.created[postID] < before {
+				delete(posts, postID)
+			}
+		}
+		if len(posts) == 0 {
+			delete(s.users, userID)
+		}
+	}
+	for postID, created := range s.created {
+		if created < before {
+			delete(s.created, postID)
+		}
+	}
+}
+
+// Snapshot returns a copy of every user's positive signals, keeping each
+// user's strongest maxPerUser, for training away from the owning actor
+func (s *Interactions) Snapshot(maxPerUser int) map[string]map[string]float64 {
+	snapshot := make(map[string]map[string]float64, len(s.users))
+	for userID, posts := range s.users {
+		positive := make([]PostRecommendation, 0, len(posts))
+		for postID, weight := range posts {
+			if weight > 0 {
+				positive = append(positive, PostRecommendation{PostID: postID, Score: weight})
+			}
+		}
+		if len(positive) == 0 {
+			continue
+		}
+		positive = topPosts(positive, maxPerUser)
+
+		copied := make(map[string]float64, len(positive))
+		for _, item := range positive {
+			copied[item.PostID] = item.Score
+		}
+		snapshot[userID] = copied
+	}
+	return snapshot
+}
+
+// PostModel holds, for each post, the posts most similar to it by the
+// cosine of their user signal vectors. It is immutable once trained, so it
+// can be built in the background and handed to the engine.
+type PostModel struct {
+	neighbours map[string][]PostRecommendation // post -> most similar posts, best first
+	// UserCoverage is the fraction of trained users the model can recommend at least one post to
+	UserCoverage float64
+}
+
+// TrainPosts builds an item-item model from signals, keeping up to
+// neighbours similar posts per post
+func TrainPosts(signals map[string]map[string]float64, neighbours int) *PostModel {
+	norms := make(map[string]float64)
+	dots := make(map[string]map[string]float64)
+	for _, posts := range signals {
+		for a, wa := range posts {
+			norms[a] += wa * wa
+			for b, wb := range posts {
+				if a == b {
+					continue
+				}
+				row, exists := dots[a]
+				if !exists {
+					row = make(map[string]float64)
+					dots[a] = row
+				}
+				row[b] += wa * wb
+			}
+		}
+	}
+
+	model := &PostModel{neighbours: make(map[string][]PostRecommendation, len(dots))}
+	for a, row := range dots {
+		similar := make([]PostRecommendation, 0, len(row))
+		for b, dot := range row {
+			similar = append(similar, PostRecommendation{PostID: b, Score: dot / math.Sqrt(norms[a]*norms[b])})
+		}
+		model.neighbours[a] = topPosts(similar, neighbours)
+	}
+
+	covered := 0
+	for _, posts := range signals {
+		if len(model.Recommend(posts, 1, nil)) > 0 {
+			covered++
+		}
+	}
+	if len(signals) > 0 {
+		model.UserCoverage = float64(covered) / float64(len(signals))
+	}
+	return model
+}
+
+// Covers reports whether the model has any similar posts for postID
+func (m *PostModel) Covers(postID string) bool {
+	return len(m.neighbours[postID]) > 0
+}
+
+// Recommend scores posts similar to those the user has positive signals on,
+// skipping anything the user has already seen, and returns up to n best
+// first; a negative n returns all. Posts for which allow returns false are
+// skipped; allow may be nil.
+func (m *PostModel) Recommend(seen map[string]float64, n int, allow func(postID string) bool) []PostRecommendation {
+	scores := make(map[string]float64)
+	for source, weight := range seen {
+		if weight <= 0 {
+			continue
+		}
+		for _, neighbour := range m.neighbours[source] {
+			if _, exists := seen[neighbour.PostID]; !exists {
+				scores[neighbour.PostID] += weight * neighbour.Score
+			}
+		}
+	}
+
+	recommendations := make([]PostRecommendation, 0, len(scores))
+	for postID, score := range scores {
+		if allow == nil || allow(postID) {
+			recommendations = append(recommendations, PostRecommendation{PostID: postID, Score: score})
+		}
+	}
+	return topPosts(recommendations, n)
+}
+
+func topPosts(recommendations []PostRecommendation, n int) []PostRecommendation {
+	sort.Slice(recommendations, func(i, j int) bool {
+		if recommendations[i].Score != recommendations[j].Score {
+			return recommendations[i].Score > recommendations[j].Score
+		}
+		return recommendations[i].PostID < recommendations[j].PostID
+	})
+	if n >= 0 && len(recommendations) > n {
+		recommendations = recommendations[:n]
+	}
+	return recommendations
+}
@@ -0,0 +1,157 @@
+package recommend
+
+import (
+	"math"
+	"sort"
+)
+
+// Recommendation is a suggested subreddit and how strongly it is suggested
+type Recommendation struct {
+	SubredditID string
+	Score       float64
+}
+
+// Recommender suggests subreddits from co-membership: the similarity of two
+// subreddits is the cosine of their member sets, and a user's score for a
+// subreddit is its similarity to each subreddit they joined, plus a smaller
+// weight for subreddits they have upvoted in. Co-membership counts are kept
+// up to date as users join, so recommending never rescans memberships.
+// It is owned by the engine actor and is not safe for concurrent use.
+type Recommender struct {
+	voteWeight float64
+	joined     map[string]map[string]bool // user -> subreddits joined
+	votes      map[string]map[string]int  // user -> subreddit -> net upvotes
+	sizes      map[string]int             // subreddit -> members
+	shared     map[string]map[string]int  // subreddit -> subreddit -> members in both
+}
+
+func NewRecommender(voteWeight float64) *Recommender {
+	return &Recommender{
+		voteWeight: voteWeight,
+		joined:     make(map[string]map[string]bool),
+		votes:      make(map[string]map[string]int),
+		sizes:      make(map[string]int),
+		shared:     make(map[string]map[string]int),
+	}
+}
+
+// Join records userID becoming a member of subredditID
+func (r *Recommender) Join(userID, subredditID string) {
+	joined, exists := r.joined[userID]
+	if !exists {
+		joined = make(map[string]bool)
+		r.joined[userID] = joined
+	}
+	if joined[subredditID] {
+		return
+	}
+
+	for other := range joined {
+		r.addShared(subredditID, other, 1)
+		r.addShared(other, subredditID, 1)
+	}
+	joined[subredditID] = true
+	r.sizes[subredditID]++
+}
+
+// Vote adds delta to userID's net upvotes in subredditID. A new vote is +1
+// or -1; changing a vote passes the difference, so a flip is +2 or -2.
+func (r *Recommender) Vote(userID, subredditID string, delta int) {
+	votes, exists := r.votes[userID]
+	if !exists {
+		votes = make(map[string]int)
+		r.votes[userID] = votes
+	}
+	votes[subredditID] += delta
+}
+
+func (r *Recommender) addShared(a, b string, delta int) {
+	counts, exists := r.shared[a]
+	if !exists {
+		counts = make(map[string]int)
+		r.shared[a] = counts
+	}
+	counts[b] += delta
+	if counts[b] <= 0 {
+		delete(counts, b)
+	}
+}
+
+// Similarity returns the cosine similarity of the two subreddits' member sets
+func (r *Recommender) Similarity(a, b string) float64 {
+	shared := r.shared[a][b]
+	if shared == 0 {
+		return 0
+	}
+	return float64(shared) / math.Sqrt(float64(r.sizes[a])*float64(r.sizes[b]))
+}
+
+// Recommend returns up to n subreddits userID has not joined, best first.
+// Subreddits for which allow returns false are skipped; allow may be nil.
+func (r *Recommender) Recommend(userID string, n int, allow func(subredditID string) bool) []Recommendation {
+	joined := r.joined[userID]
+
+	// Weight each subreddit the user has engaged with; votes count for less than joining
+	weights := make(map[string]float64, len(joined))
+	for subredditID := range joined {
+		weights[subredditID] = 1
+	}
+	for subredditID, net := range r.votes[userID] {
+		if net > 0 {
+			weights[subredditID] += r.voteWeight * math.Log1p(float64(net))
+		}
+	}
+
+	scores := make(map[string]float64)
+	for source, weight := range weights {
+		for candidate := range r.shared[source] {
+			scores[candidate] += weight * r.Similarity(source, candidate)
+		}
+	}
+
+	recommendations := make([]Recommendation, 0, len(scores))
+	for subredditID, score := range scores {
+		if joined[subredditID] || (allow != nil && !allow(subredditID)) {
+			continue
+		}
+		recommendations = append(recommendations, Recommendation{SubredditID: subredditID, Score: score})
+	}
+	return top(recommendations, n)
+}
+
+// Popular returns up to n subreddits userID has not joined, largest first,
+// scored by their share of the largest subreddit's members. It serves users
+// with no history to recommend from.
+func (r *Recommender) Popular(userID string, n int, allow func(subredditID string) bool) []Recommendation {
+	largest := 0
+	for _, size := range r.sizes {
+		largest = max(largest, size)
+	}
+
+	joined := r.joined[userID]
+	recommendations := make([]Recommendation, 0, len(r.sizes))
+	for subredditID, size := range r.sizes {
+		if size == 0 || joined[subredditID] || (allow != nil && !allow(subredditID)) {
+			continue
+		}
+		recommendations = append(recommendations, Recommendation{
+			SubredditID: subredditID,
+			Score:       float64(size) / float64(largest),
+		})
+	}
+	return top(recommendations, n)
+}
+
+// top sorts by score, breaking ties by ID so results are stable, and keeps the first n
+func top(recommendations []Recommendation, n int) []Recommendation {
+	sort.Slice(recommendations, func(i, j int) bool {
+		if recommendations[i].Score != recommendations[j].Score {
+			return recommendations[i].Score > recommendations[j].Score
+		}
+		return recommendations[i].SubredditID < recommendations[j].SubredditID
+	})
+	if n >= 0 && len(recommendations) > n {
+		recommendations = recommendations[:n]
+	}
+	return recommendations
+}
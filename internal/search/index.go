@@ -0,0 +1,192 @@
+package search
+
+import (
+	"math"
+	"sort"
+)
+
+// BM25 parameters
+const (
+	k1          = 1.2
+	b           = 0.75
+	titleWeight = 2
+)
+
+type Kind int
+
+const (
+	KindPost Kind = iota
+	KindComment
+	KindSubreddit
+)
+
+// Document is a searchable post, comment or subreddit
+type Document struct {
+	ID          string
+	Kind        Kind
+	SubredditID string
+	AuthorID    string
+	Created     int64
+	Title       string
+	Body        string
+}
+
+// Query restricts a search; zero-valued filters match everything
+type Query struct {
+	Text        string
+	Kinds       []Kind
+	SubredditID string
+	AuthorID    string
+	Since       int64
+	Until       int64
+}
+
+// Hit is a matching document with its BM25 score
+type Hit struct {
+	Document
+	Score float64
+}
+
+type indexed struct {
+	Document
+	length int
+	terms  map[string]int // term -> weighted frequency
+}
+
+// Index is an in-memory inverted index ranked with BM25.
+// It is owned by the engine actor and is not safe for concurrent use.
+type Index struct {
+	docs     map[string]*indexed
+	postings map[string]map[string]*indexed // term -> key -> document
+	totalLen int
+}
+
+func NewIndex() *Index {
+	return &Index{
+		docs:     make(map[string]*indexed),
+		postings: make(map[string]map[string]*indexed),
+	}
+}
+
+func key(kind Kind, id string) string {
+	return string(rune('0'+kind)) + ":" + id
+}
+
+// Add indexes a document, replacing any earlier version with the same kind and ID
+func (ix *Index) Add(doc Document) {
+	ix.Remove(doc.Kind, doc.ID)
+
+	d := &indexed{Document: doc, terms: make(map[string]int)}
+	for _, term := range Tokenize(doc.Title) {
+		d.terms[term] += titleWeight
+		d.length += titleWeight
+	}
+	for _, term := range Tokenize(doc.Body) {
+		d.terms[term]++
+		d.length++
+	}
+
+	k := key(doc.Kind, doc.ID)
+	ix.docs[k] = d
+	ix.totalLen += d.length
+	for term := range d.terms {
+		if ix.postings[term] == nil {
+			ix.postings[term] = make(map[string]*indexed)
+		}
+		ix.postings[term][k] = d
+	}
+}
+
+// Terms is the number of distinct terms with at least one document
+func (ix *Index) Terms() int {
+	return len(ix.postings)
+}
+
+// Remove drops a document from the index
+func (ix *Index) Remove(kind Kind, id string) {
+	k := key(kind, id)
+	d, exists := ix.docs[k]
+	if !exists {
+		return
+	}
+
+	for term := range d.terms {
+		delete(ix.postings[term], k)
+		if len(ix.postings[term]) == 0 {
+			delete(ix.postings, term)
+		}
+	}
+	ix.totalLen -= d.length
+	delete(ix.docs, k)
+}
+
+// Search returns documents matching any query term, best match first
+func (ix *Index) Search(q Query) []Hit {
+	if len(ix.docs) == 0 {
+		return nil
+	}
+
+	n := float64(len(ix.docs))
+	avgLen := float64(ix.totalLen) / n
+	scores := make(map[*indexed]float64)
+
+	seen := make(map[string]bool)
+	for _, term := range Tokenize(q.Text) {
+		if seen[term] {
+			continue
+		}
+		seen[term] = true
+
+		postings := ix.postings[term]
+		df := float64(len(postings))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		for _, d := range postings {
+			if !q.matches(d.Document) {
+				continue
+			}
+			tf := float64(d.terms[term])
+			norm := tf * (k1 + 1) / (tf + k1*(1-b+b*float64(d.length)/avgLen))
+			scores[d] += idf * norm
+		}
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for d, score := range scores {
+		hits = append(hits, Hit{Document: d.Document, Score: score})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Created > hits[j].Created
+	})
+	return hits
+}
+
+func (q Query) matches(doc Document) bool {
+	if len(q.Kinds) > 0 {
+		found := false
+		for _, kind := range q.Kinds {
+			if kind == doc.Kind {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if q.SubredditID != "" && doc.SubredditID != q.SubredditID {
+		return false
+	}
+	if q.AuthorID != "" && doc.AuthorID != q.AuthorID {
+		return false
+	}
+	if q.Since > 0 && doc.Created < q.Since {
+		return false
+	}
+	if q.Until > 0 && doc.Created > q.Until {
+		return false
+	}
+	return true
+}
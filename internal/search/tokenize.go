@@ -0,0 +1,84 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "by": true, "for": true, "from": true, "has": true, "in": true,
+	"is": true, "it": true, "of": true, "on": true, "or": true, "that": true,
+	"the": true, "this": true, "to": true, "was": true, "were": true, "with": true,
+}
+
+// suffixes are stripped longest first; each maps to its replacement
+var suffixes = []struct {
+	suffix, replacement string
+}{
+	{"ational", "ate"},
+	{"ization", "ize"},
+	{"fulness", "ful"},
+	{"iveness", "ive"},
+	{"ousness", "ous"},
+	{"ements", ""},
+	{"ement", ""},
+	{"ments", ""},
+	{"ment", ""},
+	{"ness", ""},
+	{"ings", ""},
+	{"ing", ""},
+	{"ies", "y"},
+	{"ied", "y"},
+	{"edly", ""},
+	{"ly", ""},
+	{"ed", ""},
+	{"sses", "ss"},
+	{"xes", "x"},
+	{"ches", "ch"},
+	{"shes", "sh"},
+	{"s", ""},
+}
+
+// Tokenize lowercases text, splits it into words, drops stop words and stems the rest
+func Tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	tokens := make([]string, 0, len(words))
+	for _, word := range words {
+		if stopWords[word] {
+			continue
+		}
+		tokens = append(tokens, stem(word))
+	}
+	return tokens
+}
+
+// stem is a light suffix-stripping stemmer; it keeps at least three
+// characters of the word so short words are left alone.
+func stem(word string) string {
+	if strings.HasSuffix(word, "ss") {
+		return word
+	}
+	for _, s := range suffixes {
+		if strings.HasSuffix(word, s.suffix) && len(word)-len(s.suffix) >= 3 {
+			return undouble(word[:len(word)-len(s.suffix)] + s.replacement)
+		}
+	}
+	return word
+}
+
+// undouble turns "runn" (from "running") back into "run"
+func undouble(stem string) string {
+	n := len(stem)
+	if n < 4 || stem[n-1] != stem[n-2] {
+		return stem
+	}
+	switch stem[n-1] {
+	case 'l', 's', 'z', 'a', 'e', 'i', 'o', 'u':
+		return stem
+	}
+	return stem[:n-1]
+}
@@ -7,26 +7,115 @@ type Store interface {
 	// User operations
 	CreateUser(user *models.User) error
 	GetUser(id string) (*models.User, error)
+	GetUserByUsername(username string) (*models.User, error)
+	GetUserSubreddits(userID string) ([]string, error)
+	UpdateUserKarma(userID string, delta int32) error
 
 	// Subreddit operations
 	CreateSubreddit(subreddit *models.Subreddit) error
 	GetSubreddit(id string) (*models.Subreddit, error)
+	GetSubreddits() ([]*models.Subreddit, error)
 	JoinSubreddit(subredditID, userID string) error
 	LeaveSubreddit(subredditID, userID string) error
+	AddModerator(subredditID, userID string) error
+	RemoveModerator(subredditID, userID string) error
+	BanUser(subredditID string, ban *models.Ban) error
+	UnbanUser(subredditID, userID string) error
+
+	// Moderation log operations
+	AppendModLog(entry *models.ModLogEntry) error
+	GetModLog(subredditID string) ([]*models.ModLogEntry, error)
+
+	// Report and mod queue operations
+	SetReportReasons(subredditID string, reasons []string) error
+	SetFlairTemplates(subredditID string, postFlairs, userFlairs []*models.Flair) error
+	SetUserFlair(subredditID, userID, flairID string) error
+	SetVisibility(subredditID string, visibility models.Visibility) error
+	SetAutoModConfig(subredditID, config string) error
+	AddJoinRequest(subredditID string, request *models.JoinRequest) error
+	RemoveJoinRequest(subredditID, userID string) error
+	ApproveUser(subredditID, userID string) error
+	AddInvite(subredditID, userID, moderatorID string) error
+	RemoveInvite(subredditID, userID string) error
+	AddReport(item *models.QueueItem, reporterID, reason string) (*models.QueueItem, error)
+	FilterContent(item *models.QueueItem, reason string) error
+	GetModQueue(subredditID string) ([]*models.QueueItem, error)
+	ClearReports(targetID string) error
 
 	// Post operations
 	CreatePost(post *models.Post) error
 	GetPost(id string) (*models.Post, error)
 	GetSubredditPosts(subredditID string) ([]*models.Post, error)
+	GetPostsSince(since int64) ([]*models.Post, error)
+	SetPostRemoved(id string, removed bool) error
+	GetPostsByURL(url string) ([]*models.Post, error)
+	GetImagePosts(imageID string) ([]*models.Post, error)
+	GetUserPosts(userID string) ([]*models.Post, error)
+	SetPostFlair(postID string, flair *models.Flair) error
+	SetPostSticky(postID string, sticky bool) error
+	SetPostLocked(postID string, locked bool) error
+	VotePoll(postID, userID string, option int) error
+
+	// Scheduled post operations
+	SchedulePost(scheduled *models.ScheduledPost) error
+	GetScheduledPost(id string) (*models.ScheduledPost, error)
+	GetScheduledPosts() ([]*models.ScheduledPost, error)
+	UpdateScheduledPost(scheduled *models.ScheduledPost) error
+	DeleteScheduledPost(id string) error
+
+	// Session operations
+	CreateSession(session *models.Session) error
+	GetSession(id string) (*models.Session, error)
+	GetUserSessions(userID string) ([]*models.Session, error)
+	DeleteSession(id string) error
+
+	// Subscription operations
+	AddSubscription(subscription *models.Subscription) error
+	RemoveSubscription(subscription *models.Subscription) error
+	GetSubscriptions() ([]*models.Subscription, error)
+
+	// Multireddit operations
+	CreateMultireddit(multireddit *models.Multireddit) error
+	GetMultireddit(id string) (*models.Multireddit, error)
+	GetUserMultireddits(ownerID string) ([]*models.Multireddit, error)
+	UpdateMultireddit(multireddit *models.Multireddit) error
+	DeleteMultireddit(id string) error
+
+	// Blob operations
+	SaveBlob(blob *models.Blob) error
+	GetBlob(id string) (*models.Blob, error)
 
 	// Comment operations
 	AddComment(comment *models.Comment) error
+	GetComment(id string) (*models.Comment, error)
+	GetUserComments(userID string) ([]*models.Comment, error)
 	GetComments(postID string) ([]*models.Comment, error)
+	GetCommentsSince(since int64) ([]*models.Comment, error)
+	SetCommentRemoved(id string, removed bool) error
 
 	// Message operations
 	SendMessage(message *models.DirectMessage) error
 	GetMessages(userID string) ([]*models.DirectMessage, error)
+	GetConversationIDs(userID string) ([]string, error)
+	GetConversation(conversationID string) ([]*models.DirectMessage, error)
+	MarkConversationRead(conversationID, readerID string, readAt int64) error
+	DeleteMessage(messageID, userID string) error
+	BlockUser(userID, blockedID string) error
+	UnblockUser(userID, blockedID string) error
+	GetBlockedUsers(userID string) ([]string, error)
+
+	// Notification operations
+	AddNotification(notification *models.Notification) error
+	GetNotifications(userID string) ([]*models.Notification, error)
+	MarkNotificationsRead(userID string, ids []string, read bool) error
+
+	// Saved item operations
+	SaveItem(item *models.SavedItem) error
+	UnsaveItem(userID, targetID string) error
+	GetSavedItems(userID string) ([]*models.SavedItem, error)
+	GetSavedItemsSince(since int64) ([]*models.SavedItem, error)
 
 	// Vote operations
 	Vote(targetID, userID string, isUpvote bool) error
+	GetVotes(targetID string) (map[string]bool, error)
 }
@@ -4,31 +4,68 @@ package memory
 import (
 	"errors"
 	"reddit-clone/internal/models"
+	"sort"
+	"strings"
 	"sync"
 )
 
 type MemoryStore struct {
-	users      map[string]*models.User
-	subreddits map[string]*models.Subreddit
-	posts      map[string]*models.Post
-	comments   map[string]*models.Comment
-	messages   map[string][]*models.DirectMessage
-	votes      map[string]map[string]bool // targetID -> userID -> upvote/downvote
-	mu         sync.RWMutex
+	users             map[string]*models.User
+	usernames         map[string]string // lowercased username -> user ID
+	subreddits        map[string]*models.Subreddit
+	posts             map[string]*models.Post
+	comments          map[string]*models.Comment
+	messages          map[string][]*models.DirectMessage // conversationID -> messages, oldest first
+	messageIndex      map[string]*models.DirectMessage   // messageID -> message
+	userConversations map[string]map[string]bool         // userID -> conversation IDs
+	blocks            map[string]map[string]bool         // userID -> blocked user IDs
+	votes             map[string]map[string]bool         // targetID -> userID -> upvote/downvote
+	modLog            map[string][]*models.ModLogEntry   // subredditID -> entries, oldest first
+	modQueue          map[string]*models.QueueItem       // targetID -> queue item
+	notifications     map[string][]*models.Notification  // userID -> notifications, oldest first
+	saved             map[string][]*models.SavedItem     // userID -> saved items, oldest first
+	links             map[string][]string                // normalized URL -> link post IDs
+	imagePosts        map[string][]string                // blob ID -> image post IDs
+	authorPosts       map[string][]string                // userID -> post IDs, oldest first
+	authorComments    map[string][]string                // userID -> comment IDs, oldest first
+	blobs             map[string]*models.Blob
+	scheduled         map[string]*models.ScheduledPost
+	multireddits      map[string]*models.Multireddit
+	sessions          map[string]*models.Session
+	subscriptions     map[string]models.Subscription // Subscription.Key() -> subscription
+	mu                sync.RWMutex
 }
 
 func NewMemoryStore() *MemoryStore {
 	return &MemoryStore{
-		users:      make(map[string]*models.User),
-		subreddits: make(map[string]*models.Subreddit),
-		posts:      make(map[string]*models.Post),
-		comments:   make(map[string]*models.Comment),
-		messages:   make(map[string][]*models.DirectMessage),
-		votes:      make(map[string]map[string]bool),
+		users:             make(map[string]*models.User),
+		usernames:         make(map[string]string),
+		subreddits:        make(map[string]*models.Subreddit),
+		posts:             make(map[string]*models.Post),
+		comments:          make(map[string]*models.Comment),
+		messages:          make(map[string][]*models.DirectMessage),
+		messageIndex:      make(map[string]*models.DirectMessage),
+		userConversations: make(map[string]map[string]bool),
+		blocks:            make(map[string]map[string]bool),
+		votes:             make(map[string]map[string]bool),
+		modLog:            make(map[string][]*models.ModLogEntry),
+		modQueue:          make(map[string]*models.QueueItem),
+		authorPosts:       make(map[string][]string),
+		authorComments:    make(map[string][]string),
+		links:             make(map[string][]string),
+		imagePosts:        make(map[string][]string),
+		blobs:             make(map[string]*models.Blob),
+		scheduled:         make(map[string]*models.ScheduledPost),
+		multireddits:      make(map[string]*models.Multireddit),
+		sessions:          make(map[string]*models.Session),
+		subscriptions:     make(map[string]models.Subscription),
+		saved:             make(map[string][]*models.SavedItem),
+		notifications:     make(map[string][]*models.Notification),
 	}
 }
 
-// User operations
+// User operations. Users are copied in and out, since karma is updated
+// from user actors while the engine reads it.
 func (m *MemoryStore) CreateUser(user *models.User) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -36,11 +73,29 @@ func (m *MemoryStore) CreateUser(user *models.User) error {
 	if _, exists := m.users[user.ID]; exists {
 		return errors.New("user already exists")
 	}
+	username := strings.ToLower(user.Username)
+	if _, taken := m.usernames[username]; taken {
+		return errors.New("username already taken")
+	}
 
-	m.users[user.ID] = user
+	stored := *user
+	m.users[user.ID] = &stored
+	m.usernames[username] = user.ID
 	return nil
 }
 
+func (m *MemoryStore) GetUserByUsername(username string) (*models.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	id, exists := m.usernames[strings.ToLower(username)]
+	if !exists {
+		return nil, errors.New("user not found")
+	}
+	user := *m.users[id]
+	return &user, nil
+}
+
 func (m *MemoryStore) GetUser(id string) (*models.User, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -49,7 +104,38 @@ func (m *MemoryStore) GetUser(id string) (*models.User, error) {
 	if !exists {
 		return nil, errors.New("user not found")
 	}
-	return user, nil
+	copied := *user
+	return &copied, nil
+}
+
+func (m *MemoryStore) GetUserSubreddits(userID string) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if _, exists := m.users[userID]; !exists {
+		return nil, errors.New("user not found")
+	}
+
+	subreddits := make([]string, 0)
+	for id, subreddit := range m.subreddits {
+		if subreddit.Members[userID] {
+			subreddits = append(subreddits, id)
+		}
+	}
+	return subreddits, nil
+}
+
+func (m *MemoryStore) UpdateUserKarma(userID string, delta int32) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	user, exists := m.users[userID]
+	if !exists {
+		return errors.New("user not found")
+	}
+
+	user.Karma += delta
+	return nil
 }
 
 // Subreddit operations
@@ -76,6 +162,17 @@ func (m *MemoryStore) GetSubreddit(id string) (*models.Subreddit, error) {
 	return subreddit, nil
 }
 
+func (m *MemoryStore) GetSubreddits() ([]*models.Subreddit, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	subreddits := make([]*models.Subreddit, 0, len(m.subreddits))
+	for _, subreddit := range m.subreddits {
+		subreddits = append(subreddits, subreddit)
+	}
+	return subreddits, nil
+}
+
 func (m *MemoryStore) JoinSubreddit(subredditID, userID string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -105,111 +202,1152 @@ func (m *MemoryStore) LeaveSubreddit(subredditID, userID string) error {
 	return nil
 }
 
-// Post operations
-func (m *MemoryStore) CreatePost(post *models.Post) error {
+func (m *MemoryStore) AddModerator(subredditID, userID string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if _, exists := m.posts[post.ID]; exists {
-		return errors.New("post already exists")
+	subreddit, exists := m.subreddits[subredditID]
+	if !exists {
+		return errors.New("subreddit not found")
 	}
 
-	m.posts[post.ID] = post
+	for _, moderatorID := range subreddit.Moderators {
+		if moderatorID == userID {
+			return errors.New("user is already a moderator")
+		}
+	}
+	subreddit.Moderators = append(subreddit.Moderators, userID)
 	return nil
 }
 
-func (m *MemoryStore) GetPost(id string) (*models.Post, error) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+func (m *MemoryStore) RemoveModerator(subredditID, userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
-	post, exists := m.posts[id]
+	subreddit, exists := m.subreddits[subredditID]
 	if !exists {
-		return nil, errors.New("post not found")
+		return errors.New("subreddit not found")
 	}
-	return post, nil
+
+	for i, moderatorID := range subreddit.Moderators {
+		if moderatorID == userID {
+			subreddit.Moderators = append(subreddit.Moderators[:i], subreddit.Moderators[i+1:]...)
+			return nil
+		}
+	}
+	return errors.New("user is not a moderator")
 }
 
-func (m *MemoryStore) GetSubredditPosts(subredditID string) ([]*models.Post, error) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+func (m *MemoryStore) BanUser(subredditID string, ban *models.Ban) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
-	var posts []*models.Post
-	for _, post := range m.posts {
-		if post.SubredditID == subredditID {
-			posts = append(posts, post)
-		}
+	subreddit, exists := m.subreddits[subredditID]
+	if !exists {
+		return errors.New("subreddit not found")
 	}
-	return posts, nil
+
+	if subreddit.Bans == nil {
+		subreddit.Bans = make(map[string]*models.Ban)
+	}
+	subreddit.Bans[ban.UserID] = ban
+	return nil
 }
 
-// Comment operations
-func (m *MemoryStore) AddComment(comment *models.Comment) error {
+func (m *MemoryStore) UnbanUser(subredditID, userID string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if _, exists := m.comments[comment.ID]; exists {
-		return errors.New("comment already exists")
+	subreddit, exists := m.subreddits[subredditID]
+	if !exists {
+		return errors.New("subreddit not found")
 	}
 
-	m.comments[comment.ID] = comment
+	if _, banned := subreddit.Bans[userID]; !banned {
+		return errors.New("user is not banned")
+	}
+	delete(subreddit.Bans, userID)
 	return nil
 }
 
-func (m *MemoryStore) GetComments(postID string) ([]*models.Comment, error) {
+// Moderation log operations
+func (m *MemoryStore) AppendModLog(entry *models.ModLogEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.subreddits[entry.SubredditID]; !exists {
+		return errors.New("subreddit not found")
+	}
+
+	m.modLog[entry.SubredditID] = append(m.modLog[entry.SubredditID], entry)
+	return nil
+}
+
+func (m *MemoryStore) GetModLog(subredditID string) ([]*models.ModLogEntry, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	var comments []*models.Comment
-	for _, comment := range m.comments {
-		if comment.PostID == postID {
-			comments = append(comments, comment)
-		}
+	if _, exists := m.subreddits[subredditID]; !exists {
+		return nil, errors.New("subreddit not found")
 	}
-	return comments, nil
+
+	// Copy so callers never see entries appended after the read
+	entries := make([]*models.ModLogEntry, len(m.modLog[subredditID]))
+	copy(entries, m.modLog[subredditID])
+	return entries, nil
 }
 
-// Message operations
-func (m *MemoryStore) SendMessage(message *models.DirectMessage) error {
+// Report and mod queue operations
+func (m *MemoryStore) SetReportReasons(subredditID string, reasons []string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if m.messages[message.ToID] == nil {
-		m.messages[message.ToID] = make([]*models.DirectMessage, 0)
+	subreddit, exists := m.subreddits[subredditID]
+	if !exists {
+		return errors.New("subreddit not found")
 	}
-	m.messages[message.ToID] = append(m.messages[message.ToID], message)
+
+	subreddit.ReportReasons = reasons
 	return nil
 }
 
-func (m *MemoryStore) GetMessages(userID string) ([]*models.DirectMessage, error) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+func (m *MemoryStore) SetFlairTemplates(subredditID string, postFlairs, userFlairs []*models.Flair) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
-	messages, exists := m.messages[userID]
+	subreddit, exists := m.subreddits[subredditID]
 	if !exists {
-		return make([]*models.DirectMessage, 0), nil
+		return errors.New("subreddit not found")
 	}
-	return messages, nil
+
+	subreddit.PostFlairs = postFlairs
+	subreddit.UserFlairs = userFlairs
+	return nil
 }
 
-// Vote operations
-func (m *MemoryStore) Vote(targetID, userID string, isUpvote bool) error {
+// SetUserFlair assigns a user flair in a subreddit; an empty flairID clears it
+func (m *MemoryStore) SetUserFlair(subredditID, userID, flairID string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if _, exists := m.votes[targetID]; !exists {
-		m.votes[targetID] = make(map[string]bool)
+	subreddit, exists := m.subreddits[subredditID]
+	if !exists {
+		return errors.New("subreddit not found")
 	}
 
-	m.votes[targetID][userID] = isUpvote
+	if flairID == "" {
+		delete(subreddit.UserFlair, userID)
+		return nil
+	}
+	if subreddit.UserFlair == nil {
+		subreddit.UserFlair = make(map[string]string)
+	}
+	subreddit.UserFlair[userID] = flairID
+	return nil
+}
 
-	// Update karma for the target (post or comment)
-	if post, exists := m.posts[targetID]; exists {
-		if isUpvote {
-			post.Karma++
-		} else {
-			post.Karma--
+func (m *MemoryStore) SetVisibility(subredditID string, visibility models.Visibility) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	subreddit, exists := m.subreddits[subredditID]
+	if !exists {
+		return errors.New("subreddit not found")
+	}
+
+	subreddit.Visibility = visibility
+	return nil
+}
+
+func (m *MemoryStore) SetAutoModConfig(subredditID, config string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	subreddit, exists := m.subreddits[subredditID]
+	if !exists {
+		return errors.New("subreddit not found")
+	}
+
+	subreddit.AutoModConfig = config
+	return nil
+}
+
+func (m *MemoryStore) AddJoinRequest(subredditID string, request *models.JoinRequest) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	subreddit, exists := m.subreddits[subredditID]
+	if !exists {
+		return errors.New("subreddit not found")
+	}
+
+	if _, pending := subreddit.JoinRequests[request.UserID]; pending {
+		return errors.New("join request already pending")
+	}
+	if subreddit.JoinRequests == nil {
+		subreddit.JoinRequests = make(map[string]*models.JoinRequest)
+	}
+	subreddit.JoinRequests[request.UserID] = request
+	return nil
+}
+
+func (m *MemoryStore) RemoveJoinRequest(subredditID, userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	subreddit, exists := m.subreddits[subredditID]
+	if !exists {
+		return errors.New("subreddit not found")
+	}
+
+	if _, pending := subreddit.JoinRequests[userID]; !pending {
+		return errors.New("join request not found")
+	}
+	delete(subreddit.JoinRequests, userID)
+	return nil
+}
+
+// ApproveUser lets userID contribute to a restricted or private subreddit
+func (m *MemoryStore) ApproveUser(subredditID, userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	subreddit, exists := m.subreddits[subredditID]
+	if !exists {
+		return errors.New("subreddit not found")
+	}
+
+	if subreddit.Approved == nil {
+		subreddit.Approved = make(map[string]bool)
+	}
+	subreddit.Approved[userID] = true
+	return nil
+}
+
+func (m *MemoryStore) AddInvite(subredditID, userID, moderatorID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	subreddit, exists := m.subreddits[subredditID]
+	if !exists {
+		return errors.New("subreddit not found")
+	}
+
+	if subreddit.Invites == nil {
+		subreddit.Invites = make(map[string]string)
+	}
+	subreddit.Invites[userID] = moderatorID
+	return nil
+}
+
+func (m *MemoryStore) RemoveInvite(subredditID, userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	subreddit, exists := m.subreddits[subredditID]
+	if !exists {
+		return errors.New("subreddit not found")
+	}
+
+	delete(subreddit.Invites, userID)
+	return nil
+}
+
+// queueItem returns the queue entry for item's target, creating it if needed
+func (m *MemoryStore) queueItem(item *models.QueueItem) *models.QueueItem {
+	existing, exists := m.modQueue[item.TargetID]
+	if !exists {
+		existing = &models.QueueItem{
+			TargetID:    item.TargetID,
+			TargetType:  item.TargetType,
+			SubredditID: item.SubredditID,
+			Reasons:     make(map[string]int),
+			Reporters:   make(map[string]bool),
+			Created:     item.Created,
+		}
+		m.modQueue[item.TargetID] = existing
+	}
+	return existing
+}
+
+func (m *MemoryStore) AddReport(item *models.QueueItem, reporterID, reason string) (*models.QueueItem, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if existing, exists := m.modQueue[item.TargetID]; exists && existing.Reporters[reporterID] {
+		return nil, errors.New("already reported")
+	}
+
+	queued := m.queueItem(item)
+	queued.Reporters[reporterID] = true
+	queued.Reasons[reason]++
+	return queued, nil
+}
+
+func (m *MemoryStore) FilterContent(item *models.QueueItem, reason string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch item.TargetType {
+	case models.TargetPost:
+		post, exists := m.posts[item.TargetID]
+		if !exists {
+			return errors.New("post not found")
+		}
+		post.Filtered = true
+	case models.TargetComment:
+		comment, exists := m.comments[item.TargetID]
+		if !exists {
+			return errors.New("comment not found")
+		}
+		comment.Filtered = true
+	}
+
+	queued := m.queueItem(item)
+	queued.Filtered = true
+	queued.FilterReason = reason
+	return nil
+}
+
+func (m *MemoryStore) GetModQueue(subredditID string) ([]*models.QueueItem, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	items := make([]*models.QueueItem, 0)
+	for _, item := range m.modQueue {
+		if item.SubredditID == subredditID {
+			items = append(items, item)
 		}
 	}
+	return items, nil
+}
+
+// ClearReports drops a target from the mod queue. Filtered content is shown
+// again, as a moderator has now looked at it.
+func (m *MemoryStore) ClearReports(targetID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, exists := m.modQueue[targetID]
+	if !exists {
+		return errors.New("no reports for target")
+	}
+	delete(m.modQueue, targetID)
 
+	if !item.Filtered {
+		return nil
+	}
+	switch item.TargetType {
+	case models.TargetPost:
+		if post, exists := m.posts[targetID]; exists {
+			post.Filtered = false
+		}
+	case models.TargetComment:
+		if comment, exists := m.comments[targetID]; exists {
+			comment.Filtered = false
+		}
+	}
 	return nil
 }
+
+// Post operations
+func (m *MemoryStore) CreatePost(post *models.Post) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.posts[post.ID]; exists {
+		return errors.New("post already exists")
+	}
+
+	if post.CrosspostOf != "" {
+		original, exists := m.posts[post.CrosspostOf]
+		if !exists {
+			return errors.New("original post not found")
+		}
+		original.Crossposts++
+	}
+
+	m.posts[post.ID] = post
+	m.authorPosts[post.AuthorID] = append(m.authorPosts[post.AuthorID], post.ID)
+	if post.Type == models.PostLink {
+		m.links[post.URL] = append(m.links[post.URL], post.ID)
+	}
+	for _, imageID := range post.ImageIDs {
+		m.imagePosts[imageID] = append(m.imagePosts[imageID], post.ID)
+	}
+	return nil
+}
+
+func (m *MemoryStore) GetPost(id string) (*models.Post, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	post, exists := m.posts[id]
+	if !exists {
+		return nil, errors.New("post not found")
+	}
+	return post, nil
+}
+
+func (m *MemoryStore) GetSubredditPosts(subredditID string) ([]*models.Post, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var posts []*models.Post
+	for _, post := range m.posts {
+		if post.SubredditID == subredditID {
+			posts = append(posts, post)
+		}
+	}
+	return posts, nil
+}
+
+func (m *MemoryStore) GetPostsSince(since int64) ([]*models.Post, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var posts []*models.Post
+	for _, post := range m.posts {
+		if post.Created >= since {
+			posts = append(posts, post)
+		}
+	}
+	sort.Slice(posts, func(i, j int) bool {
+		return posts[i].Created < posts[j].Created
+	})
+	return posts, nil
+}
+
+func (m *MemoryStore) SetPostRemoved(id string, removed bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	post, exists := m.posts[id]
+	if !exists {
+		return errors.New("post not found")
+	}
+
+	post.Removed = removed
+	post.Approved = !removed
+	post.Filtered = false
+	if removed && post.Sticky {
+		m.unpin(post)
+	}
+	return nil
+}
+
+// SetPostSticky pins a post to the top of its subreddit after any already
+// pinned, or unpins it
+func (m *MemoryStore) SetPostSticky(postID string, sticky bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	post, exists := m.posts[postID]
+	if !exists {
+		return errors.New("post not found")
+	}
+	subreddit, exists := m.subreddits[post.SubredditID]
+	if !exists {
+		return errors.New("subreddit not found")
+	}
+	if post.Sticky == sticky {
+		return nil
+	}
+
+	if sticky {
+		post.Sticky = true
+		subreddit.Stickies = append(subreddit.Stickies, post.ID)
+		return nil
+	}
+	m.unpin(post)
+	return nil
+}
+
+// unpin clears a post's sticky flag and slot; callers hold the lock
+func (m *MemoryStore) unpin(post *models.Post) {
+	post.Sticky = false
+	subreddit, exists := m.subreddits[post.SubredditID]
+	if !exists {
+		return
+	}
+	for i, id := range subreddit.Stickies {
+		if id == post.ID {
+			subreddit.Stickies = append(subreddit.Stickies[:i:i], subreddit.Stickies[i+1:]...)
+			return
+		}
+	}
+}
+
+func (m *MemoryStore) SetPostLocked(postID string, locked bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	post, exists := m.posts[postID]
+	if !exists {
+		return errors.New("post not found")
+	}
+	post.Locked = locked
+	return nil
+}
+
+// SetPostFlair sets or, with a nil flair, clears a post's flair
+func (m *MemoryStore) SetPostFlair(postID string, flair *models.Flair) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	post, exists := m.posts[postID]
+	if !exists {
+		return errors.New("post not found")
+	}
+
+	post.Flair = flair
+	return nil
+}
+
+func (m *MemoryStore) GetUserPosts(userID string) ([]*models.Post, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	posts := make([]*models.Post, 0, len(m.authorPosts[userID]))
+	for _, postID := range m.authorPosts[userID] {
+		posts = append(posts, m.posts[postID])
+	}
+	return posts, nil
+}
+
+func (m *MemoryStore) GetPostsByURL(url string) ([]*models.Post, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	posts := make([]*models.Post, 0, len(m.links[url]))
+	for _, postID := range m.links[url] {
+		posts = append(posts, m.posts[postID])
+	}
+	return posts, nil
+}
+
+// GetImagePosts returns the posts showing an uploaded image
+func (m *MemoryStore) GetImagePosts(imageID string) ([]*models.Post, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	posts := make([]*models.Post, 0, len(m.imagePosts[imageID]))
+	for _, postID := range m.imagePosts[imageID] {
+		posts = append(posts, m.posts[postID])
+	}
+	return posts, nil
+}
+
+// VotePoll records userID's choice; each user may vote once
+func (m *MemoryStore) VotePoll(postID, userID string, option int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	post, exists := m.posts[postID]
+	if !exists || post.Poll == nil {
+		return errors.New("poll not found")
+	}
+	if option < 0 || option >= len(post.Poll.Options) {
+		return errors.New("invalid poll option")
+	}
+	if _, voted := post.Poll.Voters[userID]; voted {
+		return errors.New("already voted in this poll")
+	}
+
+	post.Poll.Voters[userID] = option
+	post.Poll.Options[option].Votes++
+	return nil
+}
+
+// Comment operations
+func (m *MemoryStore) AddComment(comment *models.Comment) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.comments[comment.ID]; exists {
+		return errors.New("comment already exists")
+	}
+
+	m.comments[comment.ID] = comment
+	m.authorComments[comment.AuthorID] = append(m.authorComments[comment.AuthorID], comment.ID)
+	return nil
+}
+
+func (m *MemoryStore) GetComment(id string) (*models.Comment, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	comment, exists := m.comments[id]
+	if !exists {
+		return nil, errors.New("comment not found")
+	}
+	return comment, nil
+}
+
+func (m *MemoryStore) GetUserComments(userID string) ([]*models.Comment, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	comments := make([]*models.Comment, 0, len(m.authorComments[userID]))
+	for _, commentID := range m.authorComments[userID] {
+		comments = append(comments, m.comments[commentID])
+	}
+	return comments, nil
+}
+
+func (m *MemoryStore) GetComments(postID string) ([]*models.Comment, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var comments []*models.Comment
+	for _, comment := range m.comments {
+		if comment.PostID == postID {
+			comments = append(comments, comment)
+		}
+	}
+	return comments, nil
+}
+
+func (m *MemoryStore) GetCommentsSince(since int64) ([]*models.Comment, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var comments []*models.Comment
+	for _, comment := range m.comments {
+		if comment.Created >= since {
+			comments = append(comments, comment)
+		}
+	}
+	sort.Slice(comments, func(i, j int) bool {
+		return comments[i].Created < comments[j].Created
+	})
+	return comments, nil
+}
+
+func (m *MemoryStore) SetCommentRemoved(id string, removed bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	comment, exists := m.comments[id]
+	if !exists {
+		return errors.New("comment not found")
+	}
+
+	comment.Removed = removed
+	comment.Approved = !removed
+	comment.Filtered = false
+	return nil
+}
+
+// Message operations
+func (m *MemoryStore) SendMessage(message *models.DirectMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.messageIndex[message.ID]; exists {
+		return errors.New("message already exists")
+	}
+
+	if message.ConversationID == "" {
+		message.ConversationID = models.ConversationID(message.FromID, message.ToID)
+	}
+	// Both participants' actors read the thread, so only copies leave the store
+	stored := *message
+	m.messages[message.ConversationID] = append(m.messages[message.ConversationID], &stored)
+	m.messageIndex[message.ID] = &stored
+
+	for _, userID := range []string{message.FromID, message.ToID} {
+		if m.userConversations[userID] == nil {
+			m.userConversations[userID] = make(map[string]bool)
+		}
+		m.userConversations[userID][message.ConversationID] = true
+	}
+	return nil
+}
+
+// GetMessages returns the messages userID has received and not deleted, oldest first
+func (m *MemoryStore) GetMessages(userID string) ([]*models.DirectMessage, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	messages := make([]*models.DirectMessage, 0)
+	for conversationID := range m.userConversations[userID] {
+		for _, message := range m.messages[conversationID] {
+			if message.ToID == userID && !message.DeletedByRecipient {
+				copied := *message
+				messages = append(messages, &copied)
+			}
+		}
+	}
+	sort.Slice(messages, func(i, j int) bool {
+		return messages[i].Timestamp < messages[j].Timestamp
+	})
+	return messages, nil
+}
+
+func (m *MemoryStore) GetConversationIDs(userID string) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	ids := make([]string, 0, len(m.userConversations[userID]))
+	for conversationID := range m.userConversations[userID] {
+		ids = append(ids, conversationID)
+	}
+	return ids, nil
+}
+
+// GetConversation returns every message in the thread, oldest first
+func (m *MemoryStore) GetConversation(conversationID string) ([]*models.DirectMessage, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	messages := make([]*models.DirectMessage, 0, len(m.messages[conversationID]))
+	for _, message := range m.messages[conversationID] {
+		copied := *message
+		messages = append(messages, &copied)
+	}
+	return messages, nil
+}
+
+// MarkConversationRead sets the read time on unread messages sent to readerID
+func (m *MemoryStore) MarkConversationRead(conversationID, readerID string, readAt int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, message := range m.messages[conversationID] {
+		if message.ToID == readerID && message.ReadAt == 0 {
+			message.ReadAt = readAt
+		}
+	}
+	return nil
+}
+
+// DeleteMessage hides a message from userID only; the other participant still sees it
+func (m *MemoryStore) DeleteMessage(messageID, userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	message, exists := m.messageIndex[messageID]
+	if !exists || !message.VisibleTo(userID) {
+		return errors.New("message not found")
+	}
+
+	if message.FromID == userID {
+		message.DeletedBySender = true
+	}
+	if message.ToID == userID {
+		message.DeletedByRecipient = true
+	}
+	return nil
+}
+
+func (m *MemoryStore) BlockUser(userID, blockedID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.users[blockedID]; !exists {
+		return errors.New("user not found")
+	}
+
+	if m.blocks[userID] == nil {
+		m.blocks[userID] = make(map[string]bool)
+	}
+	m.blocks[userID][blockedID] = true
+	return nil
+}
+
+func (m *MemoryStore) UnblockUser(userID, blockedID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.blocks[userID][blockedID] {
+		return errors.New("user is not blocked")
+	}
+	delete(m.blocks[userID], blockedID)
+	return nil
+}
+
+func (m *MemoryStore) GetBlockedUsers(userID string) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	blocked := make([]string, 0, len(m.blocks[userID]))
+	for blockedID := range m.blocks[userID] {
+		blocked = append(blocked, blockedID)
+	}
+	return blocked, nil
+}
+
+// Notification operations
+func (m *MemoryStore) AddNotification(notification *models.Notification) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.users[notification.UserID]; !exists {
+		return errors.New("user not found")
+	}
+
+	m.notifications[notification.UserID] = append(m.notifications[notification.UserID], notification)
+	return nil
+}
+
+func (m *MemoryStore) GetNotifications(userID string) ([]*models.Notification, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	notifications := make([]*models.Notification, len(m.notifications[userID]))
+	copy(notifications, m.notifications[userID])
+	return notifications, nil
+}
+
+// MarkNotificationsRead sets the read state of the given notifications, or of all of them if ids is empty
+func (m *MemoryStore) MarkNotificationsRead(userID string, ids []string, read bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	selected := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		selected[id] = true
+	}
+	for _, notification := range m.notifications[userID] {
+		if len(ids) == 0 || selected[notification.ID] {
+			notification.Read = read
+		}
+	}
+	return nil
+}
+
+// Scheduled post operations
+func (m *MemoryStore) SchedulePost(scheduled *models.ScheduledPost) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.scheduled[scheduled.ID]; exists {
+		return errors.New("scheduled post already exists")
+	}
+	if _, exists := m.posts[scheduled.ID]; exists {
+		return errors.New("post already exists")
+	}
+	m.scheduled[scheduled.ID] = scheduled
+	return nil
+}
+
+func (m *MemoryStore) GetScheduledPost(id string) (*models.ScheduledPost, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	scheduled, exists := m.scheduled[id]
+	if !exists {
+		return nil, errors.New("scheduled post not found")
+	}
+	return scheduled, nil
+}
+
+// GetScheduledPosts returns every scheduled post, soonest first
+func (m *MemoryStore) GetScheduledPosts() ([]*models.ScheduledPost, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	scheduled := make([]*models.ScheduledPost, 0, len(m.scheduled))
+	for _, item := range m.scheduled {
+		scheduled = append(scheduled, item)
+	}
+	sort.Slice(scheduled, func(i, j int) bool {
+		if scheduled[i].PublishAt != scheduled[j].PublishAt {
+			return scheduled[i].PublishAt < scheduled[j].PublishAt
+		}
+		return scheduled[i].ID < scheduled[j].ID
+	})
+	return scheduled, nil
+}
+
+func (m *MemoryStore) UpdateScheduledPost(scheduled *models.ScheduledPost) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.scheduled[scheduled.ID]; !exists {
+		return errors.New("scheduled post not found")
+	}
+	m.scheduled[scheduled.ID] = scheduled
+	return nil
+}
+
+func (m *MemoryStore) DeleteScheduledPost(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.scheduled[id]; !exists {
+		return errors.New("scheduled post not found")
+	}
+	delete(m.scheduled, id)
+	return nil
+}
+
+// Session operations
+func (m *MemoryStore) CreateSession(session *models.Session) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.sessions[session.ID]; exists {
+		return errors.New("session already exists")
+	}
+	m.sessions[session.ID] = session
+	return nil
+}
+
+func (m *MemoryStore) GetSession(id string) (*models.Session, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	session, exists := m.sessions[id]
+	if !exists {
+		return nil, errors.New("session not found")
+	}
+	return session, nil
+}
+
+func (m *MemoryStore) GetUserSessions(userID string) ([]*models.Session, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	sessions := make([]*models.Session, 0)
+	for _, session := range m.sessions {
+		if session.UserID == userID {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions, nil
+}
+
+func (m *MemoryStore) DeleteSession(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.sessions[id]; !exists {
+		return errors.New("session not found")
+	}
+	delete(m.sessions, id)
+	return nil
+}
+
+// Subscription operations. Adding a subscription that is already held is a no-op.
+func (m *MemoryStore) AddSubscription(subscription *models.Subscription) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.subscriptions[subscription.Key()] = *subscription
+	return nil
+}
+
+func (m *MemoryStore) RemoveSubscription(subscription *models.Subscription) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := subscription.Key()
+	if _, exists := m.subscriptions[key]; !exists {
+		return errors.New("subscription not found")
+	}
+	delete(m.subscriptions, key)
+	return nil
+}
+
+func (m *MemoryStore) GetSubscriptions() ([]*models.Subscription, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	subscriptions := make([]*models.Subscription, 0, len(m.subscriptions))
+	for _, subscription := range m.subscriptions {
+		subscriptions = append(subscriptions, &subscription)
+	}
+	return subscriptions, nil
+}
+
+// Multireddit operations
+func (m *MemoryStore) CreateMultireddit(multireddit *models.Multireddit) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.multireddits[multireddit.ID]; exists {
+		return errors.New("multireddit already exists")
+	}
+	m.multireddits[multireddit.ID] = multireddit
+	return nil
+}
+
+func (m *MemoryStore) GetMultireddit(id string) (*models.Multireddit, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	multireddit, exists := m.multireddits[id]
+	if !exists {
+		return nil, errors.New("multireddit not found")
+	}
+	return multireddit, nil
+}
+
+// GetUserMultireddits returns the user's multireddits ordered by name
+func (m *MemoryStore) GetUserMultireddits(ownerID string) ([]*models.Multireddit, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	multireddits := make([]*models.Multireddit, 0)
+	for _, multireddit := range m.multireddits {
+		if multireddit.OwnerID == ownerID {
+			multireddits = append(multireddits, multireddit)
+		}
+	}
+	sort.Slice(multireddits, func(i, j int) bool {
+		if multireddits[i].Name != multireddits[j].Name {
+			return multireddits[i].Name < multireddits[j].Name
+		}
+		return multireddits[i].ID < multireddits[j].ID
+	})
+	return multireddits, nil
+}
+
+func (m *MemoryStore) UpdateMultireddit(multireddit *models.Multireddit) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.multireddits[multireddit.ID]; !exists {
+		return errors.New("multireddit not found")
+	}
+	m.multireddits[multireddit.ID] = multireddit
+	return nil
+}
+
+func (m *MemoryStore) DeleteMultireddit(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.multireddits[id]; !exists {
+		return errors.New("multireddit not found")
+	}
+	delete(m.multireddits, id)
+	return nil
+}
+
+// Blob operations
+func (m *MemoryStore) SaveBlob(blob *models.Blob) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.blobs[blob.ID]; exists {
+		return errors.New("blob already exists")
+	}
+
+	m.blobs[blob.ID] = blob
+	return nil
+}
+
+func (m *MemoryStore) GetBlob(id string) (*models.Blob, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	blob, exists := m.blobs[id]
+	if !exists {
+		return nil, errors.New("blob not found")
+	}
+	return blob, nil
+}
+
+// Saved item operations
+func (m *MemoryStore) SaveItem(item *models.SavedItem) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, saved := range m.saved[item.UserID] {
+		if saved.TargetID == item.TargetID {
+			return errors.New("item already saved")
+		}
+	}
+
+	m.saved[item.UserID] = append(m.saved[item.UserID], item)
+	return nil
+}
+
+func (m *MemoryStore) UnsaveItem(userID, targetID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	items := m.saved[userID]
+	for i, saved := range items {
+		if saved.TargetID == targetID {
+			m.saved[userID] = append(items[:i:i], items[i+1:]...)
+			return nil
+		}
+	}
+	return errors.New("saved item not found")
+}
+
+func (m *MemoryStore) GetSavedItems(userID string) ([]*models.SavedItem, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	items := make([]*models.SavedItem, len(m.saved[userID]))
+	copy(items, m.saved[userID])
+	return items, nil
+}
+
+func (m *MemoryStore) GetSavedItemsSince(since int64) ([]*models.SavedItem, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var items []*models.SavedItem
+	for _, saved := range m.saved {
+		for _, item := range saved {
+			if item.Saved >= since {
+				items = append(items, item)
+			}
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Saved < items[j].Saved
+	})
+	return items, nil
+}
+
+// Vote operations
+func (m *MemoryStore) Vote(targetID, userID string, isUpvote bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.votes[targetID]; !exists {
+		m.votes[targetID] = make(map[string]bool)
+	}
+
+	// Update karma for the target (post or comment) by the change from
+	// any earlier vote, so repeated or flipped votes don't drift
+	delta := int32(1)
+	if !isUpvote {
+		delta = -1
+	}
+	if wasUpvote, voted := m.votes[targetID][userID]; voted {
+		if wasUpvote {
+			delta--
+		} else {
+			delta++
+		}
+	}
+	m.votes[targetID][userID] = isUpvote
+
+	if post, exists := m.posts[targetID]; exists {
+		post.Karma += delta
+	} else if comment, exists := m.comments[targetID]; exists {
+		comment.Karma += delta
+	}
+
+	return nil
+}
+
+// GetVotes returns the latest vote of each user on the target
+func (m *MemoryStore) GetVotes(targetID string) (map[string]bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	votes := make(map[string]bool, len(m.votes[targetID]))
+	for userID, isUpvote := range m.votes[targetID] {
+		votes[userID] = isUpvote
+	}
+	return votes, nil
+}
@@ -0,0 +1,142 @@
+package trending
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Trend is a key whose recent activity is significantly above its baseline
+type Trend struct {
+	Key      string
+	Count    int64   // events in the recent window
+	Expected float64 // events the baseline rate predicts for the window
+	Score    float64 // standard deviations above expected
+}
+
+// series is a ring of per-bucket event counts covering the baseline followed by the recent window
+type series struct {
+	counts []int32
+	newest int64 // bucket index of the most recent slot
+}
+
+// Detector counts events per key in fixed buckets and flags keys whose
+// count in the recent window is a spike against the rate over the baseline
+// period before it. Counts are treated as Poisson, so the score is
+// (observed - expected) / sqrt(expected).
+// It is owned by the engine actor and is not safe for concurrent use.
+type Detector struct {
+	bucket   time.Duration
+	window   int
+	baseline int
+	series   map[string]*series
+}
+
+// NewDetector creates a detector comparing the last window of activity with
+// the baseline period preceding it, both counted in buckets of the given width.
+func NewDetector(bucket, window, baseline time.Duration) *Detector {
+	return &Detector{
+		bucket:   bucket,
+		window:   int(window / bucket),
+		baseline: int(baseline / bucket),
+		series:   make(map[string]*series),
+	}
+}
+
+// Len is the number of keys with activity still being tracked
+func (d *Detector) Len() int {
+	return len(d.series)
+}
+
+func (d *Detector) index(t time.Time) int64 {
+	return t.UnixNano() / int64(d.bucket)
+}
+
+// Record counts one event for key at the given time
+func (d *Detector) Record(key string, at time.Time) {
+	index := d.index(at)
+	s, exists := d.series[key]
+	if !exists {
+		s = &series{counts: make([]int32, d.window+d.baseline), newest: index}
+		d.series[key] = s
+	}
+	s.advance(index)
+	if index <= s.newest-int64(len(s.counts)) {
+		return // older than anything tracked
+	}
+	s.counts[slot(index, len(s.counts))]++
+}
+
+// Trending returns keys with at least minCount events in the recent window
+// and a score of at least threshold, highest score first. Expected counts
+// below minExpected are raised to it, so keys with no history need a
+// substantial burst before they trend. Keys with no activity left in the
+// tracked period are forgotten.
+func (d *Detector) Trending(now time.Time, minCount int64, minExpected, threshold float64) []Trend {
+	index := d.index(now)
+	trends := make([]Trend, 0)
+	for key, s := range d.series {
+		s.advance(index)
+		recent, earlier := s.sums(index, d.window)
+		if recent == 0 && earlier == 0 {
+			delete(d.series, key)
+			continue
+		}
+		if recent < minCount {
+			continue
+		}
+
+		expected := minExpected
+		if d.baseline > 0 {
+			expected = math.Max(float64(earlier)*float64(d.window)/float64(d.baseline), minExpected)
+		}
+		score := (float64(recent) - expected) / math.Sqrt(expected)
+		if score >= threshold {
+			trends = append(trends, Trend{Key: key, Count: recent, Expected: expected, Score: score})
+		}
+	}
+
+	sort.Slice(trends, func(i, j int) bool {
+		if trends[i].Score != trends[j].Score {
+			return trends[i].Score > trends[j].Score
+		}
+		return trends[i].Key < trends[j].Key
+	})
+	return trends
+}
+
+// advance moves the ring forward to index, clearing the buckets it passes
+func (s *series) advance(index int64) {
+	if index <= s.newest {
+		return
+	}
+	size := int64(len(s.counts))
+	if index-s.newest >= size {
+		for i := range s.counts {
+			s.counts[i] = 0
+		}
+	} else {
+		for i := s.newest + 1; i <= index; i++ {
+			s.counts[slot(i, len(s.counts))] = 0
+		}
+	}
+	s.newest = index
+}
+
+// sums returns the counts in the last window buckets up to index and in the buckets before them
+func (s *series) sums(index int64, window int) (recent, earlier int64) {
+	size := int64(len(s.counts))
+	for i := index - size + 1; i <= index; i++ {
+		count := int64(s.counts[slot(i, len(s.counts))])
+		if i > index-int64(window) {
+			recent += count
+		} else {
+			earlier += count
+		}
+	}
+	return recent, earlier
+}
+
+func slot(index int64, size int) int {
+	return int(((index % int64(size)) + int64(size)) % int64(size))
+}
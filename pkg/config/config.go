@@ -1 +1,248 @@
-package pkg
+package config
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Config holds the engine's tunable settings
+type Config struct {
+	RateLimit       RateLimitConfig
+	Dedup           DedupConfig
+	Reports         ReportConfig
+	Posts           PostConfig
+	Leaderboards    LeaderboardConfig
+	Trending        TrendingConfig
+	Recommendations RecommendationConfig
+	ForYou          ForYouConfig
+	AutoMod         AutoModConfig
+	Multireddits    MultiredditConfig
+	Sessions        SessionConfig
+}
+
+// Limit is a token bucket: Rate tokens are added per second, up to Burst
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// RateLimitConfig holds per-action token bucket limits.
+// Keys are action names ("post", "comment", "vote", "message", "subreddit").
+type RateLimitConfig struct {
+	Enabled bool
+	// PerUser limits a user's actions across the whole site
+	PerUser map[string]Limit
+	// PerSubreddit limits a user's actions inside a single subreddit
+	PerSubreddit map[string]Limit
+	// Accounts younger than NewAccountAge or below LowKarma get their
+	// rate and burst multiplied by StrictFactor
+	NewAccountAge time.Duration
+	LowKarma      int32
+	StrictFactor  float64
+}
+
+// DedupConfig controls repost detection
+type DedupConfig struct {
+	// Window is how far back posts are compared
+	Window time.Duration
+	// MaxDistance is the largest SimHash distance (in bits) treated as a near-duplicate
+	MaxDistance int
+}
+
+// ReportConfig controls user reports
+type ReportConfig struct {
+	// FilterThreshold is the number of reports after which content is
+	// hidden pending review; 0 disables auto-filtering
+	FilterThreshold int
+	MaxReasonLength int
+}
+
+// PostConfig limits poll and image posts
+type PostConfig struct {
+	MinPollOptions      int
+	MaxPollOptions      int
+	DefaultPollDuration time.Duration
+	MaxPollDuration     time.Duration
+	MaxImagesPerPost    int
+	MaxImageBytes       int
+	MaxScheduleAhead    time.Duration
+	MinRepeatInterval   time.Duration
+	MaxStickies         int
+}
+
+// LeaderboardConfig controls leaderboard metrics
+type LeaderboardConfig struct {
+	// MetricsSize is how many top entries of each leaderboard are exported as gauges
+	MetricsSize int
+}
+
+// TrendingConfig controls spike detection. Activity in the last Window is
+// compared with the rate over the baseline period before it.
+type TrendingConfig struct {
+	Bucket            time.Duration
+	Window            time.Duration
+	SubredditBaseline time.Duration
+	PostBaseline      time.Duration
+	// MinCount is the fewest events in the window for anything to trend
+	MinCount int64
+	// MinExpected is the floor on the expected count, so new or quiet keys need a real burst
+	MinExpected float64
+	// Threshold is the score (standard deviations above expected) at which a spike is reported
+	Threshold float64
+	// MetricsSize is how many top subreddits and posts are exported as gauges
+	MetricsSize int
+}
+
+// RecommendationConfig controls subreddit recommendations
+type RecommendationConfig struct {
+	// VoteWeight scales how much upvoting in a subreddit counts next to joining it
+	VoteWeight float64
+}
+
+// ForYouConfig controls the personalised "for you" feed
+type ForYouConfig struct {
+	// RetrainInterval is how often the post similarity model is rebuilt in the background
+	RetrainInterval time.Duration
+	// MaxAge is the oldest a post can be to be trained on or recommended
+	MaxAge time.Duration
+	// Neighbours is how many similar posts are kept for each post
+	Neighbours int
+	// MaxItemsPerUser caps how many of each user's strongest signals are trained on
+	MaxItemsPerUser int
+	UpvoteWeight    float64
+	CommentWeight   float64
+	SaveWeight      float64
+}
+
+// AutoModConfig limits subreddits' AutoModerator configs
+type AutoModConfig struct {
+	MaxRules       int
+	MaxConfigBytes int
+}
+
+// MultiredditConfig limits users' custom feeds
+type MultiredditConfig struct {
+	MaxPerUser    int
+	MaxSubreddits int
+	MaxNameLength int
+}
+
+// SessionConfig controls login sessions
+type SessionConfig struct {
+	// Required rejects requests acting as a user that carry no session_id;
+	// a session_id that is given is always checked
+	Required bool
+}
+
+// Default returns the default engine configuration
+func Default() *Config {
+	return &Config{
+		RateLimit: RateLimitConfig{
+			Enabled: true,
+			PerUser: map[string]Limit{
+				"post":      {Rate: 1.0 / 60, Burst: 5},
+				"comment":   {Rate: 1.0 / 6, Burst: 20},
+				"vote":      {Rate: 2, Burst: 60},
+				"message":   {Rate: 1.0 / 10, Burst: 10},
+				"subreddit": {Rate: 1.0 / 3600, Burst: 2},
+			},
+			PerSubreddit: map[string]Limit{
+				"post":    {Rate: 1.0 / 300, Burst: 2},
+				"comment": {Rate: 1.0 / 20, Burst: 10},
+			},
+			NewAccountAge: 24 * time.Hour,
+			LowKarma:      10,
+			StrictFactor:  0.5,
+		},
+		Dedup: DedupConfig{
+			Window:      7 * 24 * time.Hour,
+			MaxDistance: 3,
+		},
+		Reports: ReportConfig{
+			FilterThreshold: 5,
+			MaxReasonLength: 100,
+		},
+		Posts: PostConfig{
+			MinPollOptions:      2,
+			MaxPollOptions:      6,
+			DefaultPollDuration: 3 * 24 * time.Hour,
+			MaxPollDuration:     7 * 24 * time.Hour,
+			MaxImagesPerPost:    20,
+			MaxImageBytes:       10 << 20,
+			MaxScheduleAhead:    90 * 24 * time.Hour,
+			MinRepeatInterval:   time.Hour,
+			MaxStickies:         2,
+		},
+		Leaderboards: LeaderboardConfig{
+			MetricsSize: 10,
+		},
+		Trending: TrendingConfig{
+			Bucket:            5 * time.Minute,
+			Window:            time.Hour,
+			SubredditBaseline: 24 * time.Hour,
+			PostBaseline:      6 * time.Hour,
+			MinCount:          10,
+			MinExpected:       1,
+			Threshold:         3,
+			MetricsSize:       10,
+		},
+		Recommendations: RecommendationConfig{
+			VoteWeight: 0.5,
+		},
+		ForYou: ForYouConfig{
+			RetrainInterval: 5 * time.Minute,
+			MaxAge:          7 * 24 * time.Hour,
+			Neighbours:      50,
+			MaxItemsPerUser: 50,
+			UpvoteWeight:    1,
+			CommentWeight:   2,
+			SaveWeight:      3,
+		},
+		AutoMod: AutoModConfig{
+			MaxRules:       100,
+			MaxConfigBytes: 64 << 10,
+		},
+		Multireddits: MultiredditConfig{
+			MaxPerUser:    100,
+			MaxSubreddits: 100,
+			MaxNameLength: 50,
+		},
+		Sessions: SessionConfig{
+			Required: true,
+		},
+	}
+}
+
+// Validate reports settings the engine cannot run with
+func (c *Config) Validate() error {
+	return c.RateLimit.Validate()
+}
+
+// Validate checks that every limit refills, so a limited user is always
+// told when they can retry
+func (c *RateLimitConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	for scope, limits := range map[string]map[string]Limit{"per-user": c.PerUser, "per-subreddit": c.PerSubreddit} {
+		for action, limit := range limits {
+			if limit.Rate <= 0 || limit.Burst < 1 {
+				return fmt.Errorf("%s %s rate limit needs a positive rate and burst", scope, action)
+			}
+		}
+	}
+	if c.StrictFactor <= 0 || c.StrictFactor > 1 {
+		return errors.New("rate limit strict factor must be in (0, 1]")
+	}
+	return nil
+}
+
+// Strict returns the limit scaled down for new or low-karma accounts
+func (c *RateLimitConfig) Strict(limit Limit) Limit {
+	burst := int(float64(limit.Burst) * c.StrictFactor)
+	if burst < 1 {
+		burst = 1
+	}
+	return Limit{Rate: limit.Rate * c.StrictFactor, Burst: burst}
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	dto "github.com/prometheus/client_model/go"
 	"net/http"
+	"strconv"
 	"sync"
 )
 
@@ -24,6 +25,20 @@ type RedditMetrics struct {
 	SimulatedUsers      prometheus.Gauge
 	AverageResponseTime prometheus.Gauge
 	ErrorRate           prometheus.Gauge
+	ActorFailures       *prometheus.CounterVec
+	ActorRestarts       *prometheus.CounterVec
+	RateLimited         *prometheus.CounterVec
+	RepostsDetected     *prometheus.CounterVec
+	EventsPushed        *prometheus.CounterVec
+	Subscribers         prometheus.Gauge
+	LeaderboardScore    *prometheus.GaugeVec
+	TrendingScore       *prometheus.GaugeVec
+	TrendingSpikes      *prometheus.CounterVec
+	ForYouCoverage      *prometheus.GaugeVec
+	ForYouTraining      prometheus.Histogram
+	ForYouServed        *prometheus.CounterVec
+	ScheduledPosts      *prometheus.CounterVec
+	AutoModActions      *prometheus.CounterVec
 }
 
 type PersonaStats struct {
@@ -88,6 +103,63 @@ func NewRedditMetrics() *RedditMetrics {
 				Name: "reddit_error_rate",
 				Help: "Rate of errors per second",
 			}),
+			ActorFailures: promauto.NewCounterVec(prometheus.CounterOpts{
+				Name: "reddit_actor_failures_total",
+				Help: "Total number of actor panics by actor kind and offending message type",
+			}, []string{"actor", "message"}),
+			ActorRestarts: promauto.NewCounterVec(prometheus.CounterOpts{
+				Name: "reddit_actor_restarts_total",
+				Help: "Total number of actor restarts by actor kind",
+			}, []string{"actor"}),
+			RateLimited: promauto.NewCounterVec(prometheus.CounterOpts{
+				Name: "reddit_rate_limited_total",
+				Help: "Total number of requests rejected by the rate limiter by action",
+			}, []string{"action"}),
+			RepostsDetected: promauto.NewCounterVec(prometheus.CounterOpts{
+				Name: "reddit_reposts_detected_total",
+				Help: "Total number of duplicate posts detected by match type and scope",
+			}, []string{"match", "scope"}),
+			EventsPushed: promauto.NewCounterVec(prometheus.CounterOpts{
+				Name: "reddit_events_pushed_total",
+				Help: "Total number of events pushed to subscribed clients by event type",
+			}, []string{"event"}),
+			Subscribers: promauto.NewGauge(prometheus.GaugeOpts{
+				Name: "reddit_event_subscribers",
+				Help: "Number of client actors subscribed to live events",
+			}),
+			LeaderboardScore: promauto.NewGaugeVec(prometheus.GaugeOpts{
+				Name: "reddit_leaderboard_score",
+				Help: "Score of the top entries of each leaderboard by window and rank",
+			}, []string{"board", "window", "rank", "id"}),
+			TrendingScore: promauto.NewGaugeVec(prometheus.GaugeOpts{
+				Name: "reddit_trending_score",
+				Help: "Spike score of the top trending subreddits and posts by rank",
+			}, []string{"kind", "rank", "id"}),
+			TrendingSpikes: promauto.NewCounterVec(prometheus.CounterOpts{
+				Name: "reddit_trending_spikes_total",
+				Help: "Total number of subreddits and posts that started trending",
+			}, []string{"kind"}),
+			ForYouCoverage: promauto.NewGaugeVec(prometheus.GaugeOpts{
+				Name: "reddit_for_you_coverage_ratio",
+				Help: "Fraction of recent posts the for-you model can relate to others, and of active users it can recommend to",
+			}, []string{"scope"}),
+			ForYouTraining: promauto.NewHistogram(prometheus.HistogramOpts{
+				Name:    "reddit_for_you_training_seconds",
+				Help:    "Time taken to retrain the for-you post model",
+				Buckets: prometheus.DefBuckets,
+			}),
+			ForYouServed: promauto.NewCounterVec(prometheus.CounterOpts{
+				Name: "reddit_for_you_feeds_total",
+				Help: "Total number of for-you feeds served by the model or by the hot fallback",
+			}, []string{"source"}),
+			ScheduledPosts: promauto.NewCounterVec(prometheus.CounterOpts{
+				Name: "reddit_scheduled_posts_total",
+				Help: "Total number of scheduled posts published or dropped when due",
+			}, []string{"result"}),
+			AutoModActions: promauto.NewCounterVec(prometheus.CounterOpts{
+				Name: "reddit_automod_actions_total",
+				Help: "Total number of actions taken by AutoModerator rules",
+			}, []string{"action"}),
 		}
 
 	})
@@ -172,6 +244,78 @@ func (m *RedditMetrics) RecordError() {
 	m.ErrorCount.Inc()
 }
 
+// RecordActorFailure counts a failure escalated to a supervisor
+func (m *RedditMetrics) RecordActorFailure(actorKind, messageType string) {
+	m.ActorFailures.WithLabelValues(actorKind, messageType).Inc()
+}
+
+// RecordActorRestart counts an actor restart
+func (m *RedditMetrics) RecordActorRestart(actorKind string) {
+	m.ActorRestarts.WithLabelValues(actorKind).Inc()
+}
+
+// RecordRateLimited counts a request rejected by the rate limiter
+func (m *RedditMetrics) RecordRateLimited(action string) {
+	m.RateLimited.WithLabelValues(action).Inc()
+}
+
+// RecordRepost counts a detected duplicate post
+func (m *RedditMetrics) RecordRepost(match, scope string) {
+	m.RepostsDetected.WithLabelValues(match, scope).Inc()
+}
+
+// RecordEventPushed counts an event delivered to subscribers
+func (m *RedditMetrics) RecordEventPushed(event string, subscribers int) {
+	m.EventsPushed.WithLabelValues(event).Add(float64(subscribers))
+}
+
+// ResetLeaderboards clears the leaderboard gauges before they are republished
+func (m *RedditMetrics) ResetLeaderboards() {
+	m.LeaderboardScore.Reset()
+}
+
+// SetLeaderboardScore exports the score of one ranked leaderboard entry
+func (m *RedditMetrics) SetLeaderboardScore(board, window string, rank int, id string, score float64) {
+	m.LeaderboardScore.WithLabelValues(board, window, strconv.Itoa(rank), id).Set(score)
+}
+
+// ResetTrending clears the trending gauges before they are republished
+func (m *RedditMetrics) ResetTrending() {
+	m.TrendingScore.Reset()
+}
+
+// SetTrendingScore exports the score of one ranked trending subreddit or post
+func (m *RedditMetrics) SetTrendingScore(kind string, rank int, id string, score float64) {
+	m.TrendingScore.WithLabelValues(kind, strconv.Itoa(rank), id).Set(score)
+}
+
+// RecordTrendingSpike counts a subreddit or post that started trending
+func (m *RedditMetrics) RecordTrendingSpike(kind string) {
+	m.TrendingSpikes.WithLabelValues(kind).Inc()
+}
+
+// RecordForYouTrained records a retrained for-you model and its coverage
+func (m *RedditMetrics) RecordForYouTrained(duration, postCoverage, userCoverage float64) {
+	m.ForYouTraining.Observe(duration)
+	m.ForYouCoverage.WithLabelValues("posts").Set(postCoverage)
+	m.ForYouCoverage.WithLabelValues("users").Set(userCoverage)
+}
+
+// RecordForYouServed counts a for-you feed by where its posts came from
+func (m *RedditMetrics) RecordForYouServed(source string) {
+	m.ForYouServed.WithLabelValues(source).Inc()
+}
+
+// RecordScheduledPost counts a due scheduled post by whether it was published
+func (m *RedditMetrics) RecordScheduledPost(result string) {
+	m.ScheduledPosts.WithLabelValues(result).Inc()
+}
+
+// RecordAutoModAction counts an action taken by an AutoModerator rule
+func (m *RedditMetrics) RecordAutoModAction(action string) {
+	m.AutoModActions.WithLabelValues(action).Inc()
+}
+
 // RecordRequest records the duration of a request
 func (m *RedditMetrics) RecordRequest(duration float64) {
 	m.ResponseTime.Observe(duration)
@@ -1 +1,275 @@
 package integration
+
+import (
+	"github.com/asynkron/protoactor-go/actor"
+	pb "reddit-clone/api/proto/generated"
+	engine "reddit-clone/internal/actor"
+	"reddit-clone/internal/store/memory"
+	"reddit-clone/pkg/config"
+	"reddit-clone/pkg/metrics"
+	"testing"
+	"time"
+)
+
+// harness runs an engine over an empty memory store
+type harness struct {
+	t      *testing.T
+	system *actor.ActorSystem
+	engine *actor.PID
+}
+
+func newHarness(t *testing.T) *harness {
+	t.Helper()
+	cfg := config.Default()
+	cfg.RateLimit.Enabled = false // tests post faster than any real user could
+
+	system := actor.NewActorSystem()
+	pid := system.Root.Spawn(engine.NewEngineProps(memory.NewMemoryStore(), metrics.NewRedditMetrics(), cfg))
+	t.Cleanup(func() { system.Root.Stop(pid) })
+	return &harness{t: t, system: system, engine: pid}
+}
+
+func (h *harness) request(msg interface{}) interface{} {
+	h.t.Helper()
+	result, err := h.system.Root.RequestFuture(h.engine, msg, 5*time.Second).Result()
+	if err != nil {
+		h.t.Fatalf("%T: %v", msg, err)
+	}
+	return result
+}
+
+// succeed sends msg and fails the test unless the engine accepts it
+func (h *harness) succeed(msg interface{}) interface{} {
+	h.t.Helper()
+	result := h.request(msg)
+	if failure, ok := result.(*pb.ErrorResponse); ok {
+		h.t.Fatalf("%T: unexpected error %q", msg, failure.Error)
+	}
+	return result
+}
+
+// fail sends msg and fails the test unless the engine rejects it with code
+func (h *harness) fail(msg interface{}, code pb.ErrorCode) {
+	h.t.Helper()
+	result := h.request(msg)
+	failure, ok := result.(*pb.ErrorResponse)
+	if !ok {
+		h.t.Fatalf("%T: got %T, want an error with code %v", msg, result, code)
+	}
+	if failure.Code != code {
+		h.t.Fatalf("%T: got code %v (%q), want %v", msg, failure.Code, failure.Error, code)
+	}
+}
+
+// login registers userID and returns the ID of a new session for them
+func (h *harness) login(userID string) string {
+	h.t.Helper()
+	h.succeed(&pb.UserMessage{UserId: userID, Username: userID, Password: "hunter2"})
+	response, ok := h.succeed(&pb.LoginMessage{UserId: userID, Password: "hunter2"}).(*pb.LoginResponse)
+	if !ok {
+		h.t.Fatalf("login %s: no session returned", userID)
+	}
+	return response.SessionId
+}
+
+func (h *harness) subredditPosts(subredditID, userID string) []*pb.PostMessage {
+	h.t.Helper()
+	response, ok := h.succeed(&pb.GetSubredditPostsMessage{SubredditId: subredditID, UserId: userID}).(*pb.FeedResponse)
+	if !ok {
+		h.t.Fatalf("posts of %s: not a feed", subredditID)
+	}
+	return response.Posts
+}
+
+func TestBannedUserCannotParticipate(t *testing.T) {
+	h := newHarness(t)
+	mod, user := h.login("mod"), h.login("user")
+
+	h.succeed(&pb.SubredditMessage{Id: "golang", Name: "golang", CreatorId: "mod", SessionId: mod})
+	h.succeed(&pb.JoinSubredditMessage{SubredditId: "golang", UserId: "user", SessionId: user})
+	h.succeed(&pb.PostMessage{Id: "p1", SubredditId: "golang", AuthorId: "mod", Title: "Welcome", SessionId: mod})
+
+	ban := &pb.BanUserMessage{SubredditId: "golang", ModeratorId: "mod", UserId: "user", Reason: "spam"}
+	h.fail(ban, pb.ErrorCode_UNAUTHENTICATED)
+	ban.SessionId = user
+	h.fail(ban, pb.ErrorCode_UNAUTHENTICATED)
+	h.fail(&pb.BanUserMessage{SubredditId: "golang", ModeratorId: "user", UserId: "mod", SessionId: user}, pb.ErrorCode_FORBIDDEN)
+	ban.SessionId = mod
+	h.succeed(ban)
+
+	h.fail(&pb.PostMessage{Id: "p2", SubredditId: "golang", AuthorId: "user", Title: "Buy now", SessionId: user}, pb.ErrorCode_BANNED)
+	h.fail(&pb.CommentMessage{Id: "c1", PostId: "p1", AuthorId: "user", Content: "hi", SessionId: user}, pb.ErrorCode_BANNED)
+	h.fail(&pb.VoteMessage{TargetId: "p1", UserId: "user", IsUpvote: true, SessionId: user}, pb.ErrorCode_BANNED)
+
+	h.succeed(&pb.UnbanUserMessage{SubredditId: "golang", ModeratorId: "mod", UserId: "user", SessionId: mod})
+	h.succeed(&pb.CommentMessage{Id: "c1", PostId: "p1", AuthorId: "user", Content: "hi", SessionId: user})
+}
+
+func TestPrivateSubredditAccess(t *testing.T) {
+	h := newHarness(t)
+	mod, user := h.login("mod"), h.login("user")
+
+	h.succeed(&pb.SubredditMessage{Id: "secret", Name: "secret", CreatorId: "mod", Visibility: pb.Visibility_VISIBILITY_PRIVATE, SessionId: mod})
+	h.succeed(&pb.PostMessage{Id: "p1", SubredditId: "secret", AuthorId: "mod", Title: "Members only", SessionId: mod})
+
+	h.fail(&pb.GetSubredditPostsMessage{SubredditId: "secret", UserId: "user"}, pb.ErrorCode_FORBIDDEN)
+	h.fail(&pb.GetPostMessage{PostId: "p1", UserId: "user"}, pb.ErrorCode_NOT_FOUND)
+	h.fail(&pb.CommentMessage{Id: "c1", PostId: "p1", AuthorId: "user", Content: "hi", SessionId: user}, pb.ErrorCode_FORBIDDEN)
+	h.fail(&pb.SaveMessage{UserId: "user", TargetId: "p1", SessionId: user}, pb.ErrorCode_NOT_FOUND)
+
+	// Asking to join only files a request
+	h.succeed(&pb.JoinSubredditMessage{SubredditId: "secret", UserId: "user", SessionId: user})
+	h.fail(&pb.GetSubredditPostsMessage{SubredditId: "secret", UserId: "user"}, pb.ErrorCode_FORBIDDEN)
+
+	h.fail(&pb.InviteMessage{ModeratorId: "mod", SubredditId: "secret", UserId: "user"}, pb.ErrorCode_UNAUTHENTICATED)
+	h.succeed(&pb.InviteMessage{ModeratorId: "mod", SubredditId: "secret", UserId: "user", SessionId: mod})
+	h.succeed(&pb.JoinSubredditMessage{SubredditId: "secret", UserId: "user", SessionId: user})
+
+	if posts := h.subredditPosts("secret", "user"); len(posts) != 1 || posts[0].Id != "p1" {
+		t.Fatalf("invited member sees %v, want [p1]", posts)
+	}
+	h.succeed(&pb.CommentMessage{Id: "c1", PostId: "p1", AuthorId: "user", Content: "hi", SessionId: user})
+	h.succeed(&pb.PostMessage{Id: "p2", SubredditId: "secret", AuthorId: "user", Title: "Thanks for the invite", SessionId: user})
+}
+
+func TestScheduledPostPublishes(t *testing.T) {
+	if testing.Short() {
+		t.Skip("waits for the engine's housekeeping tick")
+	}
+	h := newHarness(t)
+	mod, user := h.login("mod"), h.login("user")
+
+	h.succeed(&pb.SubredditMessage{Id: "golang", Name: "golang", CreatorId: "mod", SessionId: mod})
+	publishAt := time.Now().Add(time.Second).Unix()
+	h.succeed(&pb.PostMessage{Id: "p1", SubredditId: "golang", AuthorId: "mod", Title: "Weekly thread", PublishAt: publishAt, SessionId: mod})
+
+	if posts := h.subredditPosts("golang", ""); len(posts) != 0 {
+		t.Fatalf("scheduled post visible before it is due: %v", posts)
+	}
+	h.fail(&pb.GetScheduledPostsMessage{UserId: "mod"}, pb.ErrorCode_UNAUTHENTICATED)
+	scheduled, ok := h.succeed(&pb.GetScheduledPostsMessage{UserId: "mod", SessionId: mod}).(*pb.ScheduledPostsResponse)
+	if !ok || len(scheduled.Posts) != 1 || scheduled.Posts[0].Id != "p1" {
+		t.Fatalf("scheduled posts = %v, want [p1]", scheduled)
+	}
+	h.fail(&pb.CancelScheduledPostMessage{Id: "p1", UserId: "user", SessionId: user}, pb.ErrorCode_FORBIDDEN)
+
+	deadline := time.Now().Add(15 * time.Second)
+	for len(h.subredditPosts("golang", "")) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("scheduled post was never published")
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	scheduled = h.succeed(&pb.GetScheduledPostsMessage{UserId: "mod", SessionId: mod}).(*pb.ScheduledPostsResponse)
+	if len(scheduled.Posts) != 0 {
+		t.Fatalf("one-off post still scheduled after publishing: %v", scheduled.Posts)
+	}
+}
+
+func TestStickyAndLockRejections(t *testing.T) {
+	h := newHarness(t)
+	mod, user := h.login("mod"), h.login("user")
+
+	h.succeed(&pb.SubredditMessage{Id: "golang", Name: "golang", CreatorId: "mod", SessionId: mod})
+	for _, id := range []string{"p1", "p2", "p3"} {
+		h.succeed(&pb.PostMessage{Id: id, SubredditId: "golang", AuthorId: "mod", Title: "Post " + id, SessionId: mod})
+	}
+
+	h.fail(&pb.StickyPostMessage{PostId: "p1", ModeratorId: "user", Sticky: true, SessionId: user}, pb.ErrorCode_FORBIDDEN)
+	h.fail(&pb.StickyPostMessage{PostId: "p1", ModeratorId: "mod", Sticky: true, SessionId: user}, pb.ErrorCode_UNAUTHENTICATED)
+	h.succeed(&pb.StickyPostMessage{PostId: "p1", ModeratorId: "mod", Sticky: true, SessionId: mod})
+	h.succeed(&pb.StickyPostMessage{PostId: "p2", ModeratorId: "mod", Sticky: true, SessionId: mod})
+	h.fail(&pb.StickyPostMessage{PostId: "p3", ModeratorId: "mod", Sticky: true, SessionId: mod}, pb.ErrorCode_ERROR_UNKNOWN)
+
+	if posts := h.subredditPosts("golang", ""); len(posts) != 3 || posts[0].Id != "p1" || posts[1].Id != "p2" {
+		t.Fatalf("stickies not pinned in order: %v", posts)
+	}
+
+	h.fail(&pb.LockPostMessage{PostId: "p1", ModeratorId: "user", Locked: true, SessionId: user}, pb.ErrorCode_FORBIDDEN)
+	h.succeed(&pb.LockPostMessage{PostId: "p1", ModeratorId: "mod", Locked: true, SessionId: mod})
+	h.fail(&pb.CommentMessage{Id: "c1", PostId: "p1", AuthorId: "user", Content: "hi", SessionId: user}, pb.ErrorCode_LOCKED)
+	h.succeed(&pb.CommentMessage{Id: "c2", PostId: "p1", AuthorId: "mod", Content: "Locked for now", SessionId: mod})
+
+	h.succeed(&pb.LockPostMessage{PostId: "p1", ModeratorId: "mod", SessionId: mod})
+	h.succeed(&pb.CommentMessage{Id: "c1", PostId: "p1", AuthorId: "user", Content: "hi", SessionId: user})
+}
+
+func TestMultiredditFeed(t *testing.T) {
+	h := newHarness(t)
+	mod, user, other := h.login("mod"), h.login("user"), h.login("other")
+
+	for _, id := range []string{"golang", "rust", "python"} {
+		h.succeed(&pb.SubredditMessage{Id: id, Name: id, CreatorId: "mod", SessionId: mod})
+		h.succeed(&pb.PostMessage{Id: id + "-post", SubredditId: id, AuthorId: "mod", Title: "About " + id, SessionId: mod})
+	}
+	h.succeed(&pb.SubredditMessage{Id: "secret", Name: "secret", CreatorId: "mod", Visibility: pb.Visibility_VISIBILITY_PRIVATE, SessionId: mod})
+	h.succeed(&pb.PostMessage{Id: "secret-post", SubredditId: "secret", AuthorId: "mod", Title: "Hidden", SessionId: mod})
+
+	create := &pb.CreateMultiredditMessage{Id: "langs", OwnerId: "user", Name: "Languages", SubredditIds: []string{"golang", "rust", "secret"}}
+	h.fail(create, pb.ErrorCode_UNAUTHENTICATED)
+	create.SessionId = user
+	h.succeed(create)
+
+	// Only subreddits in the multireddit the viewer can read are included
+	feed, ok := h.succeed(&pb.GetFeedMessage{MultiredditId: "langs", UserId: "user"}).(*pb.FeedResponse)
+	if !ok {
+		t.Fatal("multireddit feed: not a feed")
+	}
+	got := make(map[string]bool)
+	for _, post := range feed.Posts {
+		got[post.Id] = true
+	}
+	if len(got) != 2 || !got["golang-post"] || !got["rust-post"] {
+		t.Fatalf("multireddit feed = %v, want golang-post and rust-post", got)
+	}
+
+	h.fail(&pb.GetFeedMessage{MultiredditId: "langs", UserId: "other"}, pb.ErrorCode_FORBIDDEN)
+	h.fail(&pb.CopyMultiredditMessage{Id: "langs", UserId: "other", NewId: "mine", SessionId: other}, pb.ErrorCode_FORBIDDEN)
+	h.fail(&pb.UpdateMultiredditMessage{Id: "langs", UserId: "other", Name: "Mine", Public: true, SessionId: other}, pb.ErrorCode_FORBIDDEN)
+	h.fail(&pb.UpdateMultiredditMessage{Id: "langs", UserId: "user", Name: "Languages", Public: true, SessionId: other}, pb.ErrorCode_UNAUTHENTICATED)
+
+	h.succeed(&pb.UpdateMultiredditMessage{Id: "langs", UserId: "user", Name: "Languages", SubredditIds: []string{"golang", "python"}, Public: true, SessionId: user})
+	feed = h.succeed(&pb.GetFeedMessage{MultiredditId: "langs", UserId: "other"}).(*pb.FeedResponse)
+	if len(feed.Posts) != 2 {
+		t.Fatalf("public multireddit feed has %d posts, want 2", len(feed.Posts))
+	}
+
+	h.fail(&pb.DeleteMultiredditMessage{Id: "langs", UserId: "user"}, pb.ErrorCode_UNAUTHENTICATED)
+	h.succeed(&pb.DeleteMultiredditMessage{Id: "langs", UserId: "user", SessionId: user})
+	h.fail(&pb.GetFeedMessage{MultiredditId: "langs", UserId: "user"}, pb.ErrorCode_NOT_FOUND)
+}
+
+func TestRepeatedVotesDoNotDriftKarma(t *testing.T) {
+	h := newHarness(t)
+	author, voter := h.login("author"), h.login("voter")
+
+	h.succeed(&pb.SubredditMessage{Id: "golang", Name: "golang", CreatorId: "author", SessionId: author})
+	h.succeed(&pb.PostMessage{Id: "p1", SubredditId: "golang", AuthorId: "author", Title: "Generics", SessionId: author})
+
+	karma := func() (int32, int32) {
+		t.Helper()
+		// Karma reaches the author through their actor, so wait for it to catch up
+		h.succeed(&pb.GetUserMessage{UserId: "author"})
+		profile, ok := h.succeed(&pb.GetUserProfileMessage{UserId: "author"}).(*pb.UserProfileResponse)
+		if !ok {
+			t.Fatal("profile: not a profile")
+		}
+		return profile.Karma, profile.PostKarma
+	}
+
+	for _, step := range []struct {
+		upvote bool
+		want   int32
+	}{
+		{upvote: true, want: 1},
+		{upvote: true, want: 1},
+		{upvote: false, want: -1},
+		{upvote: false, want: -1},
+		{upvote: true, want: 1},
+	} {
+		h.succeed(&pb.VoteMessage{TargetId: "p1", UserId: "voter", IsUpvote: step.upvote, SessionId: voter})
+		if user, post := karma(); user != step.want || post != step.want {
+			t.Fatalf("after upvote=%v: karma %d, post karma %d, want %d", step.upvote, user, post, step.want)
+		}
+	}
+}
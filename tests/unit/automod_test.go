@@ -0,0 +1,107 @@
+package unit
+
+import (
+	"reddit-clone/internal/automod"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseAutoModConfig(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		rules int
+		err   string // substring of the expected error; empty for success
+	}{
+		{"empty rule list", `{"rules": []}`, 0, ""},
+		{"valid", `{"rules": [{"name": "spam", "content": "buy now", "action": "remove"}]}`, 1, ""},
+		{"empty input", ``, 0, "invalid config"},
+		{"not JSON", `rules: []`, 0, "invalid config"},
+		{"wrong shape", `{"rules": {"name": "spam"}}`, 0, "invalid config"},
+		{"unknown field", `{"rules": [{"name": "spam", "contents": "x", "action": "remove"}]}`, 0, "unknown field"},
+		{"too many rules", `{"rules": [{"name": "a", "content": "x", "action": "remove"}, {"name": "b", "content": "x", "action": "remove"}, {"name": "c", "content": "x", "action": "remove"}]}`, 0, "at most 2 rules"},
+		{"missing name", `{"rules": [{"name": " ", "content": "x", "action": "remove"}]}`, 0, "need a name"},
+		{"duplicate name", `{"rules": [{"name": "a", "content": "x", "action": "remove"}, {"name": "a", "content": "y", "action": "filter"}]}`, 0, "duplicate name"},
+		{"unknown type", `{"rules": [{"name": "a", "type": "link", "content": "x", "action": "remove"}]}`, 0, "unknown type"},
+		{"unknown action", `{"rules": [{"name": "a", "content": "x", "action": "ban"}]}`, 0, "unknown action"},
+		{"post condition on comments", `{"rules": [{"name": "a", "title": "x", "action": "remove"}]}`, 0, `need "type": "post"`},
+		{"bad regexp", `{"rules": [{"name": "a", "content": "(", "action": "remove"}]}`, 0, "content"},
+		{"bad title regexp", `{"rules": [{"name": "a", "type": "post", "title": "[", "action": "remove"}]}`, 0, "title"},
+		{"empty domain", `{"rules": [{"name": "a", "type": "post", "domains": [" "], "action": "remove"}]}`, 0, "domains cannot be empty"},
+		{"bad duration", `{"rules": [{"name": "a", "account_age_below": "3 days", "action": "filter"}]}`, 0, "positive duration"},
+		{"negative duration", `{"rules": [{"name": "a", "account_age_below": "-1h", "action": "filter"}]}`, 0, "positive duration"},
+		{"negative reports", `{"rules": [{"name": "a", "reports_at_least": -1, "content": "x", "action": "filter"}]}`, 0, "cannot be negative"},
+		{"no condition", `{"rules": [{"name": "a", "action": "remove"}]}`, 0, "at least one condition"},
+		{"no action", `{"rules": [{"name": "a", "content": "x"}]}`, 0, "at least one action"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rules, err := automod.Parse(test.text, 2)
+			if test.err == "" {
+				if err != nil {
+					t.Fatalf("Parse: %v", err)
+				}
+				if len(rules) != test.rules {
+					t.Errorf("got %d rules, want %d", len(rules), test.rules)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("Parse error = %v, want one containing %q", err, test.err)
+			}
+		})
+	}
+}
+
+func TestEvaluateAutoModRules(t *testing.T) {
+	now := time.Unix(1_000_000, 0)
+	rules, err := automod.Parse(`{"rules": [
+		{"name": "spam", "content": "(?i)buy now", "action": "remove"},
+		{"name": "shortener", "type": "post", "domains": ["bit.ly"], "action": "filter"},
+		{"name": "new accounts", "type": "comment", "account_age_below": "24h", "karma_below": 5, "action": "filter"},
+		{"name": "help flair", "type": "post", "flairs": ["help"], "title": "^\\[", "set_flair": "question"},
+		{"name": "reported", "reports_at_least": 3, "action": "filter"}
+	]}`, 10)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	oldAccount := now.Add(-48 * time.Hour).Unix()
+	tests := []struct {
+		name string
+		item automod.Item
+		want []string
+	}{
+		{"nothing matches", automod.Item{Post: true, Title: "hello", AuthorCreated: oldAccount}, nil},
+		{"empty item", automod.Item{}, nil},
+		{"content in a comment", automod.Item{Content: "BUY NOW cheap", AuthorCreated: oldAccount}, []string{"spam"}},
+		{"domain", automod.Item{Post: true, Domain: "bit.ly", AuthorCreated: oldAccount}, []string{"shortener"}},
+		{"subdomain", automod.Item{Post: true, Domain: "Go.Bit.ly", AuthorCreated: oldAccount}, []string{"shortener"}},
+		{"not a subdomain", automod.Item{Post: true, Domain: "notbit.ly", AuthorCreated: oldAccount}, nil},
+		{"domain rule skips comments", automod.Item{Domain: "bit.ly", AuthorCreated: oldAccount}, nil},
+		{"new low-karma commenter", automod.Item{AuthorKarma: 1, AuthorCreated: now.Add(-time.Hour).Unix()}, []string{"new accounts"}},
+		{"new but with karma", automod.Item{AuthorKarma: 5, AuthorCreated: now.Add(-time.Hour).Unix()}, nil},
+		{"flair and title", automod.Item{Post: true, Title: "[Q] help", FlairID: "help", AuthorCreated: oldAccount}, []string{"help flair"}},
+		{"flair without title", automod.Item{Post: true, Title: "help", FlairID: "help", AuthorCreated: oldAccount}, nil},
+		{"several rules in order", automod.Item{Post: true, Content: "buy now", Domain: "bit.ly", AuthorCreated: oldAccount}, []string{"spam", "shortener"}},
+		{"below report count", automod.Item{Reports: 2, AuthorCreated: oldAccount}, nil},
+		{"report count on new content", automod.Item{Reports: 3, AuthorCreated: oldAccount}, []string{"reported"}},
+		{"reaching the report count", automod.Item{Reports: 3, Reported: true, AuthorCreated: oldAccount}, []string{"reported"}},
+		{"past the report count", automod.Item{Reports: 4, Reported: true, AuthorCreated: oldAccount}, nil},
+		{"report skips rules without a report condition", automod.Item{Content: "buy now", Reports: 3, Reported: true, AuthorCreated: oldAccount}, []string{"reported"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.item.Now = now
+			matched := rules.Evaluate(test.item)
+			names := make([]string, 0, len(matched))
+			for _, rule := range matched {
+				names = append(names, rule.Name)
+			}
+			if strings.Join(names, ",") != strings.Join(test.want, ",") {
+				t.Errorf("matched %v, want %v", names, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package unit
+
+import (
+	"reddit-clone/internal/dedup"
+	"testing"
+	"time"
+)
+
+func TestNewFingerprint(t *testing.T) {
+	tests := []struct {
+		name        string
+		a, b        [2]string // title, content
+		exact       bool
+		maxDistance int // upper bound on the SimHash distance
+	}{
+		{"empty", [2]string{"", ""}, [2]string{"", ""}, true, 0},
+		{"case and punctuation ignored", [2]string{"Hello, World!", ""}, [2]string{"hello world", ""}, true, 0},
+		{"title and content joined", [2]string{"hello", "world"}, [2]string{"hello world", ""}, true, 0},
+		{"one word changed", [2]string{"the quick brown fox jumps over the lazy dog near the river bank today", ""},
+			[2]string{"the quick brown fox jumps over the lazy cat near the river bank today", ""}, false, 20},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a := dedup.NewFingerprint(test.a[0], test.a[1])
+			b := dedup.NewFingerprint(test.b[0], test.b[1])
+			if exact := a.Hash == b.Hash; exact != test.exact {
+				t.Errorf("exact = %v, want %v", exact, test.exact)
+			}
+			if distance := a.Distance(b); distance > test.maxDistance {
+				t.Errorf("distance = %d, want at most %d", distance, test.maxDistance)
+			}
+		})
+	}
+}
+
+func TestDetectorCheck(t *testing.T) {
+	base := dedup.Fingerprint{Hash: 1, SimHash: 0xffff0000ffff0000}
+	now := time.Unix(1_000_000, 0)
+	window := time.Hour
+
+	tests := []struct {
+		name        string
+		subredditID string
+		fingerprint dedup.Fingerprint
+		created     int64 // of the indexed post
+		local       *dedup.Match
+		other       *dedup.Match
+	}{
+		{
+			name:        "exact in same subreddit",
+			subredditID: "a",
+			fingerprint: base,
+			created:     now.Unix(),
+			local:       &dedup.Match{PostID: "p", SubredditID: "a", Exact: true},
+		},
+		{
+			name:        "exact in other subreddit",
+			subredditID: "b",
+			fingerprint: base,
+			created:     now.Unix(),
+			other:       &dedup.Match{PostID: "p", SubredditID: "a", Exact: true},
+		},
+		{
+			name:        "near within distance",
+			subredditID: "a",
+			fingerprint: dedup.Fingerprint{Hash: 2, SimHash: base.SimHash ^ 0b101},
+			created:     now.Unix(),
+			local:       &dedup.Match{PostID: "p", SubredditID: "a", Distance: 2},
+		},
+		{
+			name:        "too far",
+			subredditID: "a",
+			fingerprint: dedup.Fingerprint{Hash: 2, SimHash: base.SimHash ^ 0b1111},
+			created:     now.Unix(),
+		},
+		{
+			name:        "on the window edge",
+			subredditID: "a",
+			fingerprint: base,
+			created:     now.Add(-window).Unix(),
+			local:       &dedup.Match{PostID: "p", SubredditID: "a", Exact: true},
+		},
+		{
+			name:        "out of the window",
+			subredditID: "a",
+			fingerprint: base,
+			created:     now.Add(-window).Unix() - 1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := dedup.NewDetector(window, 3)
+			d.Add("p", "a", base, test.created)
+
+			local, other := d.Check(test.subredditID, test.fingerprint, now)
+			checkDuplicate(t, "local", local, test.local)
+			checkDuplicate(t, "other", other, test.other)
+		})
+	}
+}
+
+func checkDuplicate(t *testing.T, name string, got, want *dedup.Match) {
+	t.Helper()
+	switch {
+	case got == nil && want == nil:
+	case got == nil || want == nil:
+		t.Errorf("%s = %+v, want %+v", name, got, want)
+	case *got != *want:
+		t.Errorf("%s = %+v, want %+v", name, *got, *want)
+	}
+}
+
+func TestDetectorPrefersClosest(t *testing.T) {
+	now := time.Unix(1_000_000, 0)
+	d := dedup.NewDetector(time.Hour, 3)
+	target := dedup.Fingerprint{Hash: 1, SimHash: 0}
+	d.Add("far", "a", dedup.Fingerprint{Hash: 2, SimHash: 0b111}, now.Unix())
+	d.Add("near", "a", dedup.Fingerprint{Hash: 3, SimHash: 0b1}, now.Unix())
+	d.Add("exact", "a", dedup.Fingerprint{Hash: 1, SimHash: 0b11}, now.Unix())
+
+	if local, _ := d.Check("a", target, now); local == nil || local.PostID != "exact" {
+		t.Errorf("local = %+v, want the exact match", local)
+	}
+}
+
+func TestDetectorEvictsInOrder(t *testing.T) {
+	start := time.Unix(1_000_000, 0)
+	d := dedup.NewDetector(time.Hour, 0)
+	// Rebuilt posts can arrive out of order
+	d.Add("newer", "a", dedup.Fingerprint{Hash: 1}, start.Add(time.Hour).Unix())
+	d.Add("older", "a", dedup.Fingerprint{Hash: 2}, start.Unix())
+
+	now := start.Add(90 * time.Minute)
+	if local, _ := d.Check("a", dedup.Fingerprint{Hash: 2, SimHash: 1 << 63}, now); local != nil {
+		t.Errorf("older post still matched after the window: %+v", local)
+	}
+	if local, _ := d.Check("a", dedup.Fingerprint{Hash: 1, SimHash: 1 << 63}, now); local == nil || local.PostID != "newer" {
+		t.Errorf("local = %+v, want the newer post", local)
+	}
+	if d.Len() != 1 {
+		t.Errorf("%d posts remembered after eviction, want 1", d.Len())
+	}
+}
+
+func TestNewDetectorClampsDistance(t *testing.T) {
+	// Past 3 bits a near-duplicate could differ in every 16-bit band and be
+	// missed, so larger distances are clamped rather than matched unevenly
+	now := time.Unix(1_000_000, 0)
+	d := dedup.NewDetector(time.Hour, 10)
+	d.Add("p", "a", dedup.Fingerprint{Hash: 1}, now.Unix())
+	if local, _ := d.Check("a", dedup.Fingerprint{Hash: 2, SimHash: 0b1111}, now); local != nil {
+		t.Errorf("matched %d bits apart: %+v", local.Distance, local)
+	}
+}
@@ -0,0 +1,79 @@
+package unit
+
+import (
+	"reddit-clone/internal/leaderboard"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type boardEvent struct {
+	key   string
+	delta int64
+	at    time.Duration // after the start
+}
+
+func TestTop(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []boardEvent
+		window leaderboard.Window
+		n      int
+		now    time.Duration // after the start
+		want   []leaderboard.Entry
+	}{
+		{"empty board", nil, leaderboard.AllTime, 3, 0, []leaderboard.Entry{}},
+		{"n of zero", []boardEvent{{"a", 1, 0}}, leaderboard.AllTime, 0, 0, nil},
+		{"ranked best first", []boardEvent{{"a", 1, 0}, {"b", 3, 0}, {"c", 2, 0}}, leaderboard.AllTime, 3, 0,
+			[]leaderboard.Entry{{ID: "b", Score: 3}, {ID: "c", Score: 2}, {ID: "a", Score: 1}}},
+		{"cut to n", []boardEvent{{"a", 1, 0}, {"b", 3, 0}, {"c", 2, 0}}, leaderboard.AllTime, 2, 0,
+			[]leaderboard.Entry{{ID: "b", Score: 3}, {ID: "c", Score: 2}}},
+		{"ties broken by key", []boardEvent{{"b", 2, 0}, {"a", 2, 0}, {"c", 2, 0}}, leaderboard.AllTime, 2, 0,
+			[]leaderboard.Entry{{ID: "a", Score: 2}, {ID: "b", Score: 2}}},
+		{"scores summed", []boardEvent{{"a", 2, 0}, {"a", 3, time.Hour}, {"b", 4, 0}}, leaderboard.AllTime, 2, time.Hour,
+			[]leaderboard.Entry{{ID: "a", Score: 5}, {ID: "b", Score: 4}}},
+		{"zero delta ignored", []boardEvent{{"a", 0, 0}}, leaderboard.AllTime, 1, 0, []leaderboard.Entry{}},
+		{"cancelled out", []boardEvent{{"a", 2, 0}, {"a", -2, 0}}, leaderboard.Day, 1, 0, []leaderboard.Entry{}},
+		{"negative scores ranked last", []boardEvent{{"a", -1, 0}, {"b", 1, 0}}, leaderboard.Week, 2, 0,
+			[]leaderboard.Entry{{ID: "b", Score: 1}, {ID: "a", Score: -1}}},
+		{"day rolls over", []boardEvent{{"old", 5, 0}, {"new", 1, 23 * time.Hour}}, leaderboard.Day, 2, 25 * time.Hour,
+			[]leaderboard.Entry{{ID: "new", Score: 1}}},
+		{"week keeps the day", []boardEvent{{"old", 5, 0}, {"new", 1, 23 * time.Hour}}, leaderboard.Week, 2, 25 * time.Hour,
+			[]leaderboard.Entry{{ID: "old", Score: 5}, {ID: "new", Score: 1}}},
+		{"all time never rolls over", []boardEvent{{"old", 5, 0}}, leaderboard.AllTime, 1, 30 * 24 * time.Hour,
+			[]leaderboard.Entry{{ID: "old", Score: 5}}},
+		{"late event outside the window", []boardEvent{{"new", 1, 48 * time.Hour}, {"late", 5, 0}}, leaderboard.Day, 2, 48 * time.Hour,
+			[]leaderboard.Entry{{ID: "new", Score: 1}}},
+		{"late event inside the window", []boardEvent{{"new", 1, 10 * time.Hour}, {"late", 5, 0}}, leaderboard.Day, 2, 10 * time.Hour,
+			[]leaderboard.Entry{{ID: "late", Score: 5}, {ID: "new", Score: 1}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			start := time.Unix(1_000_000*3600, 0) // on a bucket boundary
+			board := leaderboard.NewBoard()
+			for _, e := range test.events {
+				board.Add(e.key, e.delta, start.Add(e.at))
+			}
+			if got := board.Top(test.window, test.n, start.Add(test.now)); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Top = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestScore(t *testing.T) {
+	start := time.Unix(1_000_000*3600, 0)
+	board := leaderboard.NewBoard()
+	board.Add("a", 4, start)
+	board.Add("a", 1, start.Add(2*24*time.Hour))
+
+	now := start.Add(2 * 24 * time.Hour)
+	for window, want := range map[leaderboard.Window]int64{leaderboard.AllTime: 5, leaderboard.Week: 5, leaderboard.Day: 1} {
+		if got := board.Score(window, "a", now); got != want {
+			t.Errorf("Score(%d) = %d, want %d", window, got, want)
+		}
+	}
+	if got := board.Score(leaderboard.Day, "missing", now); got != 0 {
+		t.Errorf("Score of an unknown key = %d, want 0", got)
+	}
+}
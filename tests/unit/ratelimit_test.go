@@ -0,0 +1,114 @@
+package unit
+
+import (
+	"reddit-clone/internal/ratelimit"
+	"reddit-clone/pkg/config"
+	"testing"
+	"time"
+)
+
+func newTestLimiter(now *time.Time) *ratelimit.Limiter {
+	return ratelimit.NewLimiterWithClock(func() time.Time { return *now })
+}
+
+func TestAllow(t *testing.T) {
+	limit := config.Limit{Rate: 1, Burst: 2}
+	tests := []struct {
+		name    string
+		calls   []time.Duration // offset of each call from the start
+		allowed []bool
+		wait    time.Duration // wait returned by the last call
+	}{
+		{"burst", []time.Duration{0, 0}, []bool{true, true}, 0},
+		{"over burst", []time.Duration{0, 0, 0}, []bool{true, true, false}, time.Second},
+		{"partial refill", []time.Duration{0, 0, 500 * time.Millisecond}, []bool{true, true, false}, 500 * time.Millisecond},
+		{"refilled", []time.Duration{0, 0, time.Second}, []bool{true, true, true}, 0},
+		{"refill capped at burst", []time.Duration{0, 0, time.Hour, time.Hour, time.Hour}, []bool{true, true, true, true, false}, time.Second},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			start := time.Unix(1000, 0)
+			now := start
+			l := newTestLimiter(&now)
+
+			var wait time.Duration
+			for i, offset := range test.calls {
+				now = start.Add(offset)
+				var ok bool
+				ok, wait = l.Allow("key", limit)
+				if ok != test.allowed[i] {
+					t.Fatalf("call %d: allowed = %v, want %v", i, ok, test.allowed[i])
+				}
+			}
+			if wait != test.wait {
+				t.Errorf("wait = %v, want %v", wait, test.wait)
+			}
+		})
+	}
+}
+
+func TestAllowAll(t *testing.T) {
+	tests := []struct {
+		name      string
+		user      config.Limit
+		subreddit config.Limit
+		allowed   bool
+		userLeft  bool // whether the user bucket can take another token afterwards
+	}{
+		{"both have tokens", config.Limit{Rate: 1, Burst: 2}, config.Limit{Rate: 1, Burst: 2}, true, true},
+		{"subreddit empty leaves user bucket alone", config.Limit{Rate: 1, Burst: 1}, config.Limit{Rate: 1, Burst: 0}, false, true},
+		{"user empty", config.Limit{Rate: 1, Burst: 0}, config.Limit{Rate: 1, Burst: 1}, false, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			now := time.Unix(1000, 0)
+			l := newTestLimiter(&now)
+
+			ok, _ := l.AllowAll(ratelimit.Request{Key: "user", Limit: test.user}, ratelimit.Request{Key: "subreddit", Limit: test.subreddit})
+			if ok != test.allowed {
+				t.Fatalf("allowed = %v, want %v", ok, test.allowed)
+			}
+			if left, _ := l.Allow("user", test.user); left != test.userLeft {
+				t.Errorf("user bucket has a token = %v, want %v", left, test.userLeft)
+			}
+		})
+	}
+}
+
+func TestAllowAllEmpty(t *testing.T) {
+	now := time.Unix(1000, 0)
+	if ok, wait := newTestLimiter(&now).AllowAll(); !ok || wait != 0 {
+		t.Errorf("AllowAll() = %v, %v, want true, 0", ok, wait)
+	}
+}
+
+func TestAllowAllWaitsForSlowest(t *testing.T) {
+	now := time.Unix(1000, 0)
+	l := newTestLimiter(&now)
+	fast := ratelimit.Request{Key: "fast", Limit: config.Limit{Rate: 2, Burst: 1}}
+	slow := ratelimit.Request{Key: "slow", Limit: config.Limit{Rate: 0.5, Burst: 1}}
+
+	if ok, _ := l.AllowAll(fast, slow); !ok {
+		t.Fatal("first request refused")
+	}
+	if ok, wait := l.AllowAll(fast, slow); ok || wait != 2*time.Second {
+		t.Errorf("AllowAll() = %v, %v, want false, 2s", ok, wait)
+	}
+}
+
+func TestPruneForgetsFullBuckets(t *testing.T) {
+	const pruneEvery = 10000 // calls between the limiter's sweeps
+	start := time.Unix(1000, 0)
+	now := start
+	l := newTestLimiter(&now)
+	limit := config.Limit{Rate: 1, Burst: 1}
+
+	l.Allow("idle", limit)
+	now = start.Add(time.Hour)
+	for i := 1; i < pruneEvery; i++ {
+		l.Allow("busy", limit)
+	}
+	if l.Len() != 1 {
+		t.Errorf("%d buckets after a sweep, want only the busy one", l.Len())
+	}
+}
@@ -0,0 +1,274 @@
+package unit
+
+import (
+	"math"
+	"reddit-clone/internal/recommend"
+	"reflect"
+	"testing"
+)
+
+// newTestRecommender has subreddit a with three members, two of whom are
+// also in b and one in c
+func newTestRecommender() *recommend.Recommender {
+	r := recommend.NewRecommender(1)
+	for userID, subreddits := range map[string][]string{
+		"u1": {"a", "b"},
+		"u2": {"a", "b"},
+		"u3": {"a", "c"},
+	} {
+		for _, subredditID := range subreddits {
+			r.Join(userID, subredditID)
+		}
+	}
+	return r
+}
+
+func subredditIDs(recommendations []recommend.Recommendation) []string {
+	ids := make([]string, 0, len(recommendations))
+	for _, recommendation := range recommendations {
+		ids = append(ids, recommendation.SubredditID)
+	}
+	return ids
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"a", "b", 2 / math.Sqrt(3*2)},
+		{"b", "a", 2 / math.Sqrt(3*2)},
+		{"a", "c", 1 / math.Sqrt(3*1)},
+		{"b", "c", 0},
+		{"a", "unknown", 0},
+	}
+	r := newTestRecommender()
+	for _, test := range tests {
+		if got := r.Similarity(test.a, test.b); math.Abs(got-test.want) > 1e-9 {
+			t.Errorf("Similarity(%s, %s) = %v, want %v", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestRecommend(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(r *recommend.Recommender)
+		n     int
+		allow func(string) bool
+		want  []string
+	}{
+		{name: "no history", n: 5, want: []string{}},
+		{name: "from a join", setup: func(r *recommend.Recommender) { r.Join("x", "a") }, n: 5, want: []string{"b", "c"}},
+		{name: "cut to n", setup: func(r *recommend.Recommender) { r.Join("x", "a") }, n: 1, want: []string{"b"}},
+		{name: "n of zero", setup: func(r *recommend.Recommender) { r.Join("x", "a") }, n: 0, want: []string{}},
+		{
+			name:  "allow filter",
+			setup: func(r *recommend.Recommender) { r.Join("x", "a") },
+			n:     5,
+			allow: func(subredditID string) bool { return subredditID != "b" },
+			want:  []string{"c"},
+		},
+		{name: "joining twice counts once", setup: func(r *recommend.Recommender) { r.Join("x", "a"); r.Join("x", "a") }, n: 5, want: []string{"b", "c"}},
+		{name: "from an upvote", setup: func(r *recommend.Recommender) { r.Vote("x", "b", 1) }, n: 5, want: []string{"a"}},
+		{name: "downvotes are no signal", setup: func(r *recommend.Recommender) { r.Vote("x", "b", -1) }, n: 5, want: []string{}},
+		{name: "flipped vote", setup: func(r *recommend.Recommender) { r.Vote("x", "b", 1); r.Vote("x", "b", -2) }, n: 5, want: []string{}},
+		{name: "retracted vote", setup: func(r *recommend.Recommender) { r.Vote("x", "b", 1); r.Vote("x", "b", -1) }, n: 5, want: []string{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := newTestRecommender()
+			if test.setup != nil {
+				test.setup(r)
+			}
+			if got := subredditIDs(r.Recommend("x", test.n, test.allow)); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Recommend = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestVoteWeightGrowsWithNetVotes(t *testing.T) {
+	one, many := newTestRecommender(), newTestRecommender()
+	one.Vote("x", "b", 1)
+	many.Vote("x", "b", 3)
+
+	a, b := one.Recommend("x", 1, nil), many.Recommend("x", 1, nil)
+	if len(a) != 1 || len(b) != 1 || b[0].Score <= a[0].Score {
+		t.Errorf("scores with one and three upvotes = %v, %v; want the second higher", a, b)
+	}
+}
+
+func TestPopular(t *testing.T) {
+	tests := []struct {
+		name   string
+		userID string
+		allow  func(string) bool
+		want   []recommend.Recommendation
+	}{
+		{"new user", "x", nil, []recommend.Recommendation{{SubredditID: "a", Score: 1}, {SubredditID: "b", Score: 2.0 / 3}, {SubredditID: "c", Score: 1.0 / 3}}},
+		{"joined skipped", "u3", nil, []recommend.Recommendation{{SubredditID: "b", Score: 2.0 / 3}}},
+		{"allow filter", "x", func(subredditID string) bool { return subredditID == "c" }, []recommend.Recommendation{{SubredditID: "c", Score: 1.0 / 3}}},
+	}
+	r := newTestRecommender()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := r.Popular(test.userID, 5, test.allow)
+			if len(got) != len(test.want) {
+				t.Fatalf("Popular = %v, want %v", got, test.want)
+			}
+			for i := range got {
+				if got[i].SubredditID != test.want[i].SubredditID || math.Abs(got[i].Score-test.want[i].Score) > 1e-9 {
+					t.Errorf("Popular[%d] = %v, want %v", i, got[i], test.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPopularEmpty(t *testing.T) {
+	if got := recommend.NewRecommender(1).Popular("x", 5, nil); len(got) != 0 {
+		t.Errorf("Popular on an empty recommender = %v, want none", got)
+	}
+}
+
+func TestInteractions(t *testing.T) {
+	s := recommend.NewInteractions()
+	s.Add("u1", "p1", 100, 1)
+	s.Add("u1", "p1", 100, 2)
+	s.Add("u1", "p2", 200, -1)
+	s.Add("u1", "p3", 300, 0)
+	s.Add("u2", "p1", 100, 1)
+
+	if got, want := s.Of("u1"), map[string]float64{"p1": 3, "p2": -1, "p3": 0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Of(u1) = %v, want %v", got, want)
+	}
+	if got := s.Of("unknown"); len(got) != 0 {
+		t.Errorf("Of(unknown) = %v, want none", got)
+	}
+
+	s.Prune(150)
+	if got, want := s.Of("u1"), map[string]float64{"p2": -1, "p3": 0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Of(u1) after pruning = %v, want %v", got, want)
+	}
+	if users, posts := s.Counts(); users != 1 || posts != 2 {
+		t.Errorf("%d users and %d posts kept after pruning, want 1 and 2", users, posts)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	tests := []struct {
+		name       string
+		signals    map[string]map[string]float64
+		maxPerUser int
+		want       map[string]map[string]float64
+	}{
+		{"empty", nil, 5, map[string]map[string]float64{}},
+		{
+			name:       "only positive signals",
+			signals:    map[string]map[string]float64{"u1": {"p1": 1, "p2": -1, "p3": 0}, "u2": {"p1": -2}},
+			maxPerUser: 5,
+			want:       map[string]map[string]float64{"u1": {"p1": 1}},
+		},
+		{
+			name:       "strongest kept",
+			signals:    map[string]map[string]float64{"u1": {"p1": 1, "p2": 3, "p3": 2}},
+			maxPerUser: 2,
+			want:       map[string]map[string]float64{"u1": {"p2": 3, "p3": 2}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := recommend.NewInteractions()
+			for userID, posts := range test.signals {
+				for postID, weight := range posts {
+					s.Add(userID, postID, 0, weight)
+				}
+			}
+			if got := s.Snapshot(test.maxPerUser); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Snapshot = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func testSignals() map[string]map[string]float64 {
+	return map[string]map[string]float64{
+		"u1": {"p1": 1, "p2": 1},
+		"u2": {"p1": 1, "p2": 1, "p3": 1},
+		"u3": {"p4": 1},
+	}
+}
+
+func postIDs(recommendations []recommend.PostRecommendation) []string {
+	ids := make([]string, 0, len(recommendations))
+	for _, recommendation := range recommendations {
+		ids = append(ids, recommendation.PostID)
+	}
+	return ids
+}
+
+func TestTrainPosts(t *testing.T) {
+	model := recommend.TrainPosts(testSignals(), 5)
+
+	// Seeing only p1 scores each neighbour by its similarity to p1
+	want := []recommend.PostRecommendation{{PostID: "p2", Score: 1}, {PostID: "p3", Score: 1 / math.Sqrt(2)}}
+	got := model.Recommend(map[string]float64{"p1": 1}, -1, nil)
+	if len(got) != len(want) {
+		t.Fatalf("neighbours of p1 = %v, want %v", got, want)
+	}
+	for i := range got {
+		if got[i].PostID != want[i].PostID || math.Abs(got[i].Score-want[i].Score) > 1e-9 {
+			t.Errorf("neighbours of p1 [%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+	if model.Covers("p4") {
+		t.Error("p4 has no co-signals but is covered")
+	}
+	// Only u1 has an unseen similar post
+	if math.Abs(model.UserCoverage-1.0/3) > 1e-9 {
+		t.Errorf("UserCoverage = %v, want 1/3", model.UserCoverage)
+	}
+}
+
+func TestTrainPostsEmpty(t *testing.T) {
+	model := recommend.TrainPosts(nil, 5)
+	if model.UserCoverage != 0 || model.Covers("p1") {
+		t.Errorf("empty model = %+v, want no coverage", model)
+	}
+	if got := model.Recommend(map[string]float64{"p1": 1}, 5, nil); len(got) != 0 {
+		t.Errorf("Recommend from an empty model = %v, want none", got)
+	}
+}
+
+func TestPostModelRecommend(t *testing.T) {
+	model := recommend.TrainPosts(testSignals(), 5)
+	tests := []struct {
+		name  string
+		seen  map[string]float64
+		n     int
+		allow func(string) bool
+		want  []string
+	}{
+		{name: "nothing seen", seen: nil, n: 5, want: []string{}},
+		{name: "similar posts", seen: map[string]float64{"p1": 1}, n: 5, want: []string{"p2", "p3"}},
+		{name: "seen posts skipped", seen: map[string]float64{"p1": 1, "p2": 0}, n: 5, want: []string{"p3"}},
+		{name: "negative signals are no source", seen: map[string]float64{"p1": -1}, n: 5, want: []string{}},
+		{name: "cut to n", seen: map[string]float64{"p1": 1}, n: 1, want: []string{"p2"}},
+		{name: "negative n returns all", seen: map[string]float64{"p1": 1}, n: -1, want: []string{"p2", "p3"}},
+		{
+			name:  "allow filter",
+			seen:  map[string]float64{"p1": 1},
+			n:     5,
+			allow: func(postID string) bool { return postID != "p2" },
+			want:  []string{"p3"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := postIDs(model.Recommend(test.seen, test.n, test.allow)); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Recommend = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package unit
+
+import (
+	"reddit-clone/internal/search"
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"", []string{}},
+		{"   ...  ", []string{}},
+		{"The and of", []string{}},
+		{"Running runs", []string{"run", "run"}},
+		{"studies class bus", []string{"study", "class", "bus"}},
+		{"Go 1.23, go!", []string{"go", "1", "23", "go"}},
+		{"Ünïcode words", []string{"ünïcode", "word"}},
+	}
+	for _, test := range tests {
+		t.Run(test.text, func(t *testing.T) {
+			if got := search.Tokenize(test.text); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Tokenize(%q) = %q, want %q", test.text, got, test.want)
+			}
+		})
+	}
+}
+
+func testSearchIndex() *search.Index {
+	ix := search.NewIndex()
+	for _, doc := range []search.Document{
+		{ID: "p1", Kind: search.KindPost, SubredditID: "golang", AuthorID: "alice", Created: 100, Title: "Go generics", Body: "type parameters in practice"},
+		{ID: "p2", Kind: search.KindPost, SubredditID: "rust", AuthorID: "bob", Created: 200, Title: "Rust traits", Body: "generics with trait bounds"},
+		{ID: "c1", Kind: search.KindComment, SubredditID: "golang", AuthorID: "bob", Created: 300, Body: "generics are useful"},
+		{ID: "golang", Kind: search.KindSubreddit, SubredditID: "golang", AuthorID: "alice", Created: 50, Title: "golang", Body: "the Go programming language"},
+	} {
+		ix.Add(doc)
+	}
+	return ix
+}
+
+func hitIDs(hits []search.Hit) []string {
+	ids := make([]string, 0, len(hits))
+	for _, hit := range hits {
+		ids = append(ids, hit.ID)
+	}
+	return ids
+}
+
+func TestSearch(t *testing.T) {
+	tests := []struct {
+		name  string
+		query search.Query
+		want  []string
+	}{
+		{"empty query", search.Query{}, []string{}},
+		{"only stop words", search.Query{Text: "the of"}, []string{}},
+		{"no match", search.Query{Text: "python"}, []string{}},
+		{"title outranks body", search.Query{Text: "generics", Kinds: []search.Kind{search.KindPost}}, []string{"p1", "p2"}},
+		{"kind filter", search.Query{Text: "generics", Kinds: []search.Kind{search.KindComment}}, []string{"c1"}},
+		{"subreddit filter", search.Query{Text: "generics", SubredditID: "rust"}, []string{"p2"}},
+		{"author filter", search.Query{Text: "generics", AuthorID: "bob", Kinds: []search.Kind{search.KindComment}}, []string{"c1"}},
+		{"since", search.Query{Text: "generics", Since: 250}, []string{"c1"}},
+		{"until", search.Query{Text: "generics", Until: 150}, []string{"p1"}},
+		{"stemmed", search.Query{Text: "trait", Kinds: []search.Kind{search.KindPost}}, []string{"p2"}},
+	}
+	ix := testSearchIndex()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := hitIDs(ix.Search(test.query)); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Search(%+v) = %v, want %v", test.query, got, test.want)
+			}
+		})
+	}
+}
+
+func TestSearchEmptyIndex(t *testing.T) {
+	if hits := search.NewIndex().Search(search.Query{Text: "anything"}); len(hits) != 0 {
+		t.Errorf("Search on an empty index = %v, want none", hits)
+	}
+}
+
+func TestAddReplacesAndRemove(t *testing.T) {
+	ix := testSearchIndex()
+	ix.Add(search.Document{ID: "p1", Kind: search.KindPost, SubredditID: "golang", Created: 100, Title: "Go modules"})
+
+	if got := hitIDs(ix.Search(search.Query{Text: "generics", Kinds: []search.Kind{search.KindPost}})); !reflect.DeepEqual(got, []string{"p2"}) {
+		t.Errorf("after replacing p1, generics = %v, want [p2]", got)
+	}
+	if got := hitIDs(ix.Search(search.Query{Text: "modules"})); !reflect.DeepEqual(got, []string{"p1"}) {
+		t.Errorf("after replacing p1, modules = %v, want [p1]", got)
+	}
+
+	// The same ID under another kind is a different document
+	ix.Remove(search.KindComment, "p1")
+	if len(ix.Search(search.Query{Text: "modules"})) != 1 {
+		t.Error("removing another kind dropped the post")
+	}
+
+	ix.Remove(search.KindPost, "p1")
+	if hits := ix.Search(search.Query{Text: "modules"}); len(hits) != 0 {
+		t.Errorf("removed post still found: %v", hitIDs(hits))
+	}
+	want := testSearchIndex()
+	want.Remove(search.KindPost, "p1")
+	if ix.Terms() != want.Terms() {
+		t.Errorf("%d terms indexed after removal, want %d", ix.Terms(), want.Terms())
+	}
+}
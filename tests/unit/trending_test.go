@@ -0,0 +1,138 @@
+package unit
+
+import (
+	"math"
+	"reddit-clone/internal/trending"
+	"testing"
+	"time"
+)
+
+type burst struct {
+	key   string
+	count int
+	at    time.Duration // after the start
+}
+
+func TestTrending(t *testing.T) {
+	const (
+		minCount    = 5
+		minExpected = 1
+		threshold   = 3
+	)
+	tests := []struct {
+		name     string
+		baseline time.Duration
+		bursts   []burst
+		now      time.Duration // after the start
+		want     []trending.Trend
+	}{
+		{name: "no activity", baseline: 4 * time.Hour, now: 5*time.Hour + 30*time.Minute, want: []trending.Trend{}},
+		{
+			name:     "zero baseline counts use the floor",
+			baseline: 4 * time.Hour,
+			bursts:   []burst{{"new", 10, 5 * time.Hour}},
+			now:      5*time.Hour + 30*time.Minute,
+			want:     []trending.Trend{{Key: "new", Count: 10, Expected: 1, Score: 9}},
+		},
+		{
+			name:     "zero baseline period uses the floor",
+			baseline: 0,
+			bursts:   []burst{{"new", 10, 5 * time.Hour}},
+			now:      5*time.Hour + 30*time.Minute,
+			want:     []trending.Trend{{Key: "new", Count: 10, Expected: 1, Score: 9}},
+		},
+		{
+			name:     "below min count",
+			baseline: 4 * time.Hour,
+			bursts:   []burst{{"quiet", minCount - 1, 5 * time.Hour}},
+			now:      5*time.Hour + 30*time.Minute,
+			want:     []trending.Trend{},
+		},
+		{
+			name:     "steady activity is not a spike",
+			baseline: 4 * time.Hour,
+			bursts: []burst{
+				{"steady", 10, time.Hour}, {"steady", 10, 2 * time.Hour},
+				{"steady", 10, 3 * time.Hour}, {"steady", 10, 4 * time.Hour},
+				{"steady", 10, 5 * time.Hour},
+			},
+			now:  5*time.Hour + 30*time.Minute,
+			want: []trending.Trend{},
+		},
+		{
+			name:     "spike over a baseline",
+			baseline: 4 * time.Hour,
+			bursts:   []burst{{"spike", 16, time.Hour}, {"spike", 20, 5 * time.Hour}},
+			now:      5*time.Hour + 30*time.Minute,
+			want:     []trending.Trend{{Key: "spike", Count: 20, Expected: 4, Score: 8}},
+		},
+		{
+			name:     "highest score first",
+			baseline: 4 * time.Hour,
+			bursts:   []burst{{"b", 10, 5 * time.Hour}, {"a", 17, 5 * time.Hour}},
+			now:      5*time.Hour + 30*time.Minute,
+			want: []trending.Trend{
+				{Key: "a", Count: 17, Expected: 1, Score: 16},
+				{Key: "b", Count: 10, Expected: 1, Score: 9},
+			},
+		},
+		{
+			name:     "window rolls over",
+			baseline: 4 * time.Hour,
+			bursts:   []burst{{"old", 10, 5 * time.Hour}},
+			now:      6*time.Hour + 30*time.Minute,
+			want:     []trending.Trend{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			start := time.Unix(0, 0).Add(1000 * time.Hour)
+			d := trending.NewDetector(time.Hour, time.Hour, test.baseline)
+			for _, b := range test.bursts {
+				for i := 0; i < b.count; i++ {
+					d.Record(b.key, start.Add(b.at))
+				}
+			}
+
+			got := d.Trending(start.Add(test.now), minCount, minExpected, threshold)
+			if len(got) != len(test.want) {
+				t.Fatalf("Trending = %+v, want %+v", got, test.want)
+			}
+			for i := range got {
+				if got[i].Key != test.want[i].Key || got[i].Count != test.want[i].Count ||
+					math.Abs(got[i].Expected-test.want[i].Expected) > 1e-9 || math.Abs(got[i].Score-test.want[i].Score) > 1e-9 {
+					t.Errorf("trend %d = %+v, want %+v", i, got[i], test.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestTrendingForgetsIdleKeys(t *testing.T) {
+	start := time.Unix(0, 0).Add(1000 * time.Hour)
+	d := trending.NewDetector(time.Hour, time.Hour, 2*time.Hour)
+	d.Record("idle", start)
+
+	d.Trending(start.Add(time.Hour), 1, 1, 0)
+	if d.Len() != 1 {
+		t.Fatal("key forgotten while still in the baseline")
+	}
+	d.Trending(start.Add(3*time.Hour), 1, 1, 0)
+	if d.Len() != 0 {
+		t.Error("key with no tracked activity was kept")
+	}
+}
+
+func TestRecordIgnoresUntrackedPast(t *testing.T) {
+	start := time.Unix(0, 0).Add(1000 * time.Hour)
+	d := trending.NewDetector(time.Hour, time.Hour, time.Hour)
+	d.Record("key", start.Add(5*time.Hour))
+	d.Record("key", start) // before the baseline
+
+	// Counted into the window or the baseline, the old event would raise
+	// the count or lift the expectation off the floor
+	got := d.Trending(start.Add(5*time.Hour), 1, 0.5, 0)
+	if len(got) != 1 || got[0].Count != 1 || got[0].Expected != 0.5 {
+		t.Errorf("Trending = %+v, want one event against the floor", got)
+	}
+}